@@ -0,0 +1,290 @@
+// Package adjust computes split and dividend adjustment factors for historical series that were stored unadjusted.
+//
+// A Table is built from the splits returned by ReferenceClient.ListSplits, the dividends returned by
+// ReferenceClient.ListDividends, and the unadjusted aggregates of the same ticker (used to look up the close prior to
+// each ex-dividend date). The table can then re-express aggregates, trades, and quotes in the share basis of any
+// reference date:
+//
+//	tbl := adjust.NewTable(splits, dividends, bars)
+//	adjusted := tbl.AsOf(civil.Date{Year: 2024, Month: 6, Day: 28}, adjust.TotalReturn).Aggs(bars)
+package adjust
+
+import (
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// EventKind is the type of corporate action behind an adjustment event.
+type EventKind string
+
+const (
+	EventSplit    EventKind = "split"
+	EventDividend EventKind = "dividend"
+)
+
+// Basis selects which corporate actions are applied when adjusting a series.
+type Basis int
+
+const (
+	// SplitsOnly adjusts prices and sizes for splits, which matches the server-side `adjusted=true` behavior.
+	SplitsOnly Basis = iota
+
+	// TotalReturn additionally adjusts prices for cash dividends so that the series reflects reinvested dividends.
+	TotalReturn
+)
+
+// Event is a single corporate action and the factors it applies to activity before its effective date.
+type Event struct {
+	// The kind of corporate action.
+	Kind EventKind
+
+	// The effective date of the action. This is the execution date for splits and the ex-dividend date for dividends.
+	// Activity strictly before this date is in the old basis.
+	Date civil.Date
+
+	// The multiplier applied to prices before Date to express them in the basis on or after Date.
+	PriceFactor float64
+
+	// The multiplier applied to sizes before Date to express them in the basis on or after Date.
+	SizeFactor float64
+
+	// The close used to derive a dividend factor. This is zero for splits and for dividends where no close prior to the
+	// ex-dividend date was available, in which case the dividend is recorded with a factor of 1.
+	ReferenceClose float64
+
+	// The source split if Kind is EventSplit.
+	Split *models.Split
+
+	// The source dividend if Kind is EventDividend.
+	Dividend *models.Dividend
+}
+
+// Factor is a row of the cumulative factor table. Activity dated on or after Start and before End (or without an upper
+// bound if End is the zero date) is multiplied by Price and Size to express it in the reference basis.
+type Factor struct {
+	Start civil.Date
+	End   civil.Date
+	Price float64
+	Size  float64
+}
+
+// Table holds the corporate actions of a single ticker, ordered by effective date.
+type Table struct {
+	// Location is the time zone used to map timestamps to trading dates. It defaults to America/New_York.
+	Location *time.Location
+
+	events []Event
+}
+
+// NewTable builds an adjustment table from splits, dividends, and the unadjusted aggregates of the same ticker. The
+// aggregates are only used to look up the close prior to each ex-dividend date and can be nil if dividend adjustment
+// isn't needed. Splits with a zero ratio and dividends without a positive cash amount are ignored.
+func NewTable(splits []models.Split, dividends []models.Dividend, aggs []models.Agg) *Table {
+	t := &Table{Location: tz.NewYork()}
+
+	for i := range splits {
+		s := splits[i]
+		if s.SplitFrom <= 0 || s.SplitTo <= 0 {
+			continue
+		}
+		t.events = append(t.events, Event{
+			Kind:        EventSplit,
+			Date:        s.ExecutionDate,
+			PriceFactor: s.SplitFrom / s.SplitTo,
+			SizeFactor:  s.SplitTo / s.SplitFrom,
+			Split:       &s,
+		})
+	}
+
+	closes := t.closesByDate(aggs)
+	for i := range dividends {
+		d := dividends[i]
+		if d.CashAmount <= 0 {
+			continue
+		}
+		ev := Event{
+			Kind:        EventDividend,
+			Date:        d.ExDividendDate,
+			PriceFactor: 1,
+			SizeFactor:  1,
+			Dividend:    &d,
+		}
+		if c := closes.before(d.ExDividendDate); c > d.CashAmount {
+			ev.ReferenceClose = c
+			ev.PriceFactor = 1 - d.CashAmount/c
+		}
+		t.events = append(t.events, ev)
+	}
+
+	sort.SliceStable(t.events, func(i, j int) bool {
+		return t.events[i].Date.Before(t.events[j].Date)
+	})
+
+	return t
+}
+
+// Events returns the corporate actions in the table ordered by effective date.
+func (t *Table) Events() []Event {
+	return append([]Event(nil), t.events...)
+}
+
+// Factors returns the cumulative factor table that expresses any date in the basis of asOf. Rows are ordered by start
+// date and cover every date; the row containing asOf always has factors of 1.
+func (t *Table) Factors(asOf civil.Date, basis Basis) []Factor {
+	a := t.AsOf(asOf, basis)
+
+	var bounds []civil.Date
+	for _, ev := range t.events {
+		if !basis.includes(ev.Kind) {
+			continue
+		}
+		if len(bounds) == 0 || bounds[len(bounds)-1] != ev.Date {
+			bounds = append(bounds, ev.Date)
+		}
+	}
+
+	factors := make([]Factor, 0, len(bounds)+1)
+	var start civil.Date
+	for _, b := range bounds {
+		factors = append(factors, Factor{Start: start, End: b, Price: a.PriceFactor(start), Size: a.SizeFactor(start)})
+		start = b
+	}
+	factors = append(factors, Factor{Start: start, Price: a.PriceFactor(start), Size: a.SizeFactor(start)})
+
+	return factors
+}
+
+// AsOf returns an adjustment that expresses activity in the share basis of the given reference date.
+func (t *Table) AsOf(asOf civil.Date, basis Basis) Adjustment {
+	return Adjustment{table: t, asOf: asOf, basis: basis}
+}
+
+// Adjustment applies the factors of a table as of a reference date.
+type Adjustment struct {
+	table *Table
+	asOf  civil.Date
+	basis Basis
+}
+
+// PriceFactor returns the multiplier for prices of activity on the given date.
+func (a Adjustment) PriceFactor(d civil.Date) float64 {
+	return a.factor(d, func(ev Event) float64 { return ev.PriceFactor })
+}
+
+// SizeFactor returns the multiplier for sizes of activity on the given date.
+func (a Adjustment) SizeFactor(d civil.Date) float64 {
+	return a.factor(d, func(ev Event) float64 { return ev.SizeFactor })
+}
+
+// Aggs returns a copy of the aggregates with prices, VWAP, and volume adjusted.
+func (a Adjustment) Aggs(aggs []models.Agg) []models.Agg {
+	out := make([]models.Agg, len(aggs))
+	for i, agg := range aggs {
+		d := a.table.date(time.Time(agg.Timestamp))
+		p, s := a.PriceFactor(d), a.SizeFactor(d)
+		agg.Open *= p
+		agg.High *= p
+		agg.Low *= p
+		agg.Close *= p
+		agg.VWAP *= p
+		agg.Volume *= s
+		out[i] = agg
+	}
+	return out
+}
+
+// Trades returns a copy of the trades with price and size adjusted. Trades are dated by their SIP timestamp.
+func (a Adjustment) Trades(trades []models.Trade) []models.Trade {
+	out := make([]models.Trade, len(trades))
+	for i, trade := range trades {
+		d := a.table.date(time.Time(trade.SipTimestamp))
+		trade.Price *= a.PriceFactor(d)
+		trade.Size *= a.SizeFactor(d)
+		out[i] = trade
+	}
+	return out
+}
+
+// Quotes returns a copy of the quotes with bid/ask prices and sizes adjusted. Quotes are dated by their SIP timestamp.
+func (a Adjustment) Quotes(quotes []models.Quote) []models.Quote {
+	out := make([]models.Quote, len(quotes))
+	for i, quote := range quotes {
+		d := a.table.date(time.Time(quote.SipTimestamp))
+		p, s := a.PriceFactor(d), a.SizeFactor(d)
+		quote.BidPrice *= p
+		quote.AskPrice *= p
+		quote.BidSize *= s
+		quote.AskSize *= s
+		out[i] = quote
+	}
+	return out
+}
+
+// factor multiplies the factors of every event between d and the reference date. Events after d up to and including
+// the reference date are applied as is, and events after the reference date up to and including d are inverted so
+// that later activity is expressed in the earlier basis.
+func (a Adjustment) factor(d civil.Date, f func(Event) float64) float64 {
+	x := 1.0
+	for _, ev := range a.table.events {
+		if !a.basis.includes(ev.Kind) {
+			continue
+		}
+		switch {
+		case d.Before(ev.Date) && !a.asOf.Before(ev.Date):
+			x *= f(ev)
+		case a.asOf.Before(ev.Date) && !d.Before(ev.Date):
+			x /= f(ev)
+		}
+	}
+	return x
+}
+
+func (b Basis) includes(k EventKind) bool {
+	return k == EventSplit || (k == EventDividend && b == TotalReturn)
+}
+
+func (t *Table) date(ts time.Time) civil.Date {
+	loc := t.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	return civil.DateOf(ts.In(loc))
+}
+
+type dailyCloses struct {
+	dates  []civil.Date
+	closes []float64
+}
+
+// closesByDate keeps the last close of each trading date, ordered by date.
+func (t *Table) closesByDate(aggs []models.Agg) dailyCloses {
+	sorted := append([]models.Agg(nil), aggs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return time.Time(sorted[i].Timestamp).Before(time.Time(sorted[j].Timestamp))
+	})
+
+	var dc dailyCloses
+	for _, agg := range sorted {
+		d := t.date(time.Time(agg.Timestamp))
+		if n := len(dc.dates); n > 0 && dc.dates[n-1] == d {
+			dc.closes[n-1] = agg.Close
+			continue
+		}
+		dc.dates = append(dc.dates, d)
+		dc.closes = append(dc.closes, agg.Close)
+	}
+	return dc
+}
+
+// before returns the last close strictly before d, or zero if there isn't one.
+func (dc dailyCloses) before(d civil.Date) float64 {
+	i := sort.Search(len(dc.dates), func(i int) bool { return !dc.dates[i].Before(d) })
+	if i == 0 {
+		return 0
+	}
+	return dc.closes[i-1]
+}
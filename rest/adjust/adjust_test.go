@@ -0,0 +1,109 @@
+package adjust_test
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/adjust"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	splits = []models.Split{
+		{Ticker: "AAPL", ExecutionDate: civil.Date{Year: 2020, Month: 8, Day: 31}, SplitFrom: 1, SplitTo: 4},
+	}
+	dividends = []models.Dividend{
+		{Ticker: "AAPL", ExDividendDate: civil.Date{Year: 2020, Month: 8, Day: 7}, CashAmount: 0.82},
+		{Ticker: "AAPL", ExDividendDate: civil.Date{Year: 2020, Month: 11, Day: 6}, CashAmount: 0.205},
+	}
+	bars = []models.Agg{
+		agg(2020, 8, 6, 455.61, 1000),
+		agg(2020, 8, 7, 444.45, 1000),
+		agg(2020, 8, 28, 499.23, 1000),
+		agg(2020, 8, 31, 129.04, 4000),
+		agg(2020, 11, 5, 118.69, 4000),
+		agg(2020, 11, 6, 118.69, 4000),
+	}
+)
+
+func agg(y int, m time.Month, d int, c, v float64) models.Agg {
+	ts := time.Date(y, m, d, 16, 0, 0, 0, time.UTC)
+	return models.Agg{Ticker: "AAPL", Open: c, High: c, Low: c, Close: c, VWAP: c, Volume: v, Timestamp: models.Millis(ts)}
+}
+
+func TestSplitsOnly(t *testing.T) {
+	tbl := adjust.NewTable(splits, dividends, bars)
+	out := tbl.AsOf(civil.Date{Year: 2020, Month: 12, Day: 31}, adjust.SplitsOnly).Aggs(bars)
+
+	assert.InDelta(t, 455.61/4, out[0].Close, 1e-9)
+	assert.InDelta(t, 4000, out[0].Volume, 1e-9)
+	assert.InDelta(t, 499.23/4, out[2].Close, 1e-9)
+	assert.InDelta(t, 129.04, out[3].Close, 1e-9)
+	assert.InDelta(t, 4000, out[3].Volume, 1e-9)
+
+	// the input shouldn't be modified
+	assert.Equal(t, 455.61, bars[0].Close)
+}
+
+func TestAsOfBeforeSplit(t *testing.T) {
+	tbl := adjust.NewTable(splits, nil, nil)
+	out := tbl.AsOf(civil.Date{Year: 2020, Month: 8, Day: 28}, adjust.SplitsOnly).Aggs(bars)
+
+	assert.InDelta(t, 455.61, out[0].Close, 1e-9)
+	assert.InDelta(t, 129.04*4, out[3].Close, 1e-9)
+	assert.InDelta(t, 1000, out[3].Volume, 1e-9)
+}
+
+func TestTotalReturn(t *testing.T) {
+	tbl := adjust.NewTable(splits, dividends, bars)
+	a := tbl.AsOf(civil.Date{Year: 2020, Month: 12, Day: 31}, adjust.TotalReturn)
+
+	events := tbl.Events()
+	assert.Len(t, events, 3)
+	assert.Equal(t, adjust.EventDividend, events[0].Kind)
+	assert.Equal(t, 455.61, events[0].ReferenceClose)
+	assert.Equal(t, adjust.EventSplit, events[1].Kind)
+	assert.Equal(t, 118.69, events[2].ReferenceClose)
+
+	d1 := 1 - 0.82/455.61
+	d2 := 1 - 0.205/118.69
+	assert.InDelta(t, d1*0.25*d2, a.PriceFactor(civil.Date{Year: 2020, Month: 8, Day: 6}), 1e-12)
+	assert.InDelta(t, 0.25*d2, a.PriceFactor(civil.Date{Year: 2020, Month: 8, Day: 7}), 1e-12)
+	assert.InDelta(t, d2, a.PriceFactor(civil.Date{Year: 2020, Month: 11, Day: 5}), 1e-12)
+	assert.InDelta(t, 1, a.PriceFactor(civil.Date{Year: 2020, Month: 11, Day: 6}), 1e-12)
+	assert.InDelta(t, 4, a.SizeFactor(civil.Date{Year: 2020, Month: 8, Day: 6}), 1e-12)
+
+	factors := tbl.Factors(civil.Date{Year: 2020, Month: 12, Day: 31}, adjust.TotalReturn)
+	assert.Len(t, factors, 4)
+	assert.Equal(t, civil.Date{}, factors[0].Start)
+	assert.Equal(t, civil.Date{Year: 2020, Month: 8, Day: 7}, factors[0].End)
+	assert.InDelta(t, d1*0.25*d2, factors[0].Price, 1e-12)
+	assert.Equal(t, civil.Date{}, factors[3].End)
+	assert.Equal(t, 1.0, factors[3].Price)
+}
+
+func TestTradesAndQuotes(t *testing.T) {
+	tbl := adjust.NewTable(splits, nil, nil)
+	a := tbl.AsOf(civil.Date{Year: 2021, Month: 1, Day: 4}, adjust.SplitsOnly)
+	ts := models.Nanos(time.Date(2020, 8, 28, 15, 0, 0, 0, time.UTC))
+
+	trades := a.Trades([]models.Trade{{Price: 500, Size: 100, SipTimestamp: ts}})
+	assert.InDelta(t, 125, trades[0].Price, 1e-9)
+	assert.InDelta(t, 400, trades[0].Size, 1e-9)
+
+	quotes := a.Quotes([]models.Quote{{BidPrice: 499, AskPrice: 501, BidSize: 2, AskSize: 3, SipTimestamp: ts}})
+	assert.InDelta(t, 124.75, quotes[0].BidPrice, 1e-9)
+	assert.InDelta(t, 125.25, quotes[0].AskPrice, 1e-9)
+	assert.InDelta(t, 8, quotes[0].BidSize, 1e-9)
+	assert.InDelta(t, 12, quotes[0].AskSize, 1e-9)
+}
+
+func TestMissingReferenceClose(t *testing.T) {
+	tbl := adjust.NewTable(nil, dividends[:1], nil)
+	events := tbl.Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, 1.0, events[0].PriceFactor)
+	assert.Equal(t, 0.0, events[0].ReferenceClose)
+}
@@ -0,0 +1,420 @@
+// Package calendar answers questions about trading days and sessions offline.
+//
+// A Calendar knows the holidays and early closes of a market, and the boundaries of its pre-market, regular, and
+// after-hours sessions in exchange time:
+//
+//	cal := calendar.New(calendar.NYSE)
+//	if err := cal.Refresh(ctx, c); err != nil {
+//		log.Print(err) // the bundled calendar is still usable
+//	}
+//	day, err := cal.Day(civil.Date{Year: 2024, Month: 11, Day: 29})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Print(day.EarlyClose, day.Regular.End) // true 2024-11-29 13:00:00 -0500 EST
+//
+// New returns a calendar bundled with the package, which covers the holidays and early closes announced for 2020
+// through 2027. A calendar only answers for the dates its holidays are known for, which Range returns, and fails
+// with ErrNotCovered outside of them rather than guessing. Refreshing a calendar from
+// ReferenceClient.GetMarketHolidays, which lists upcoming holidays, extends the range to the last one listed.
+//
+// The client has no other aggregate chunking or resampling helpers, so Chunks and Aggs provide them on top of the
+// calendar.
+package calendar
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+//go:embed holidays.json
+var defaultHolidays []byte
+
+// Market is a market with its own calendar.
+type Market string
+
+const (
+	NYSE   Market = "NYSE"
+	Nasdaq Market = "NASDAQ"
+
+	// OPRA is the calendar of listed options, which follow the holidays of NYSE but have no extended hours.
+	OPRA Market = "OPRA"
+)
+
+// Session is a trading session of a day.
+type Session int
+
+const (
+	Closed Session = iota
+	PreMarket
+	Regular
+	AfterHours
+)
+
+func (s Session) String() string {
+	switch s {
+	case PreMarket:
+		return "pre-market"
+	case Regular:
+		return "regular"
+	case AfterHours:
+		return "after-hours"
+	}
+	return "closed"
+}
+
+const (
+	statusClosed     = "closed"
+	statusEarlyClose = "early-close"
+)
+
+// ErrNotCovered is returned for dates outside of the range that the holidays of a calendar are known for.
+var ErrNotCovered = errors.New("date not covered by the calendar")
+
+// Getter gets market holidays. The Client of the rest package implements it.
+type Getter interface {
+	GetMarketHolidays(ctx context.Context, options ...models.RequestOption) (*models.GetMarketHolidaysResponse, error)
+}
+
+// Interval is a time range in exchange time. It includes its start but not its end.
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsZero reports whether the interval is empty, i.e. the session doesn't take place.
+func (i Interval) IsZero() bool {
+	return !i.Start.Before(i.End)
+}
+
+// Contains reports whether a time is within the interval.
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Day is a calendar day of a market.
+type Day struct {
+	Date civil.Date
+
+	// Open is whether the market trades on the day.
+	Open bool
+
+	// EarlyClose is whether the regular session closes early.
+	EarlyClose bool
+
+	// Holiday is the name of the holiday that closes the market or shortens the day.
+	Holiday string
+
+	// The sessions of the day, which are empty on days the market is closed.
+	PreMarket  Interval
+	Regular    Interval
+	AfterHours Interval
+}
+
+// Session returns the interval of a session.
+func (d Day) Session(s Session) Interval {
+	switch s {
+	case PreMarket:
+		return d.PreMarket
+	case Regular:
+		return d.Regular
+	case AfterHours:
+		return d.AfterHours
+	}
+	return Interval{}
+}
+
+// DateRange is a range of dates that includes both ends.
+type DateRange struct {
+	From civil.Date
+	To   civil.Date
+}
+
+// hours are the session boundaries of a full trading day as offsets from midnight in exchange time.
+type hours struct {
+	preMarket, open, close, afterHours time.Duration
+
+	// earlyAfterHours is how long after-hours trading lasts after an early close
+	earlyAfterHours time.Duration
+}
+
+var marketHours = map[Market]hours{
+	NYSE:   {preMarket: 4 * time.Hour, open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour, afterHours: 20 * time.Hour, earlyAfterHours: 4 * time.Hour},
+	Nasdaq: {preMarket: 4 * time.Hour, open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour, afterHours: 20 * time.Hour, earlyAfterHours: 4 * time.Hour},
+	OPRA:   {preMarket: 9*time.Hour + 30*time.Minute, open: 9*time.Hour + 30*time.Minute, close: 16 * time.Hour, afterHours: 16 * time.Hour},
+}
+
+// Calendar is the calendar of a market. It's safe for concurrent use.
+type Calendar struct {
+	market Market
+	hours  hours
+	loc    *time.Location
+
+	mtx      sync.RWMutex
+	holidays map[civil.Date]models.MarketHoliday
+	covered  DateRange
+}
+
+// New creates the bundled calendar of a market.
+func New(market Market) *Calendar {
+	var holidays models.GetMarketHolidaysResponse
+	if err := json.Unmarshal(defaultHolidays, &holidays); err != nil {
+		panic(fmt.Sprintf("invalid bundled holidays: %v", err))
+	}
+	c := NewFromHolidays(market, holidays)
+
+	// the bundle lists every holiday of the years it spans
+	c.covered.From = civil.Date{Year: c.covered.From.Year, Month: time.January, Day: 1}
+	c.covered.To = civil.Date{Year: c.covered.To.Year, Month: time.December, Day: 31}
+	return c
+}
+
+// NewFromHolidays creates the calendar of a market from a list of holidays of any exchange. Holidays of other
+// exchanges are ignored. The calendar covers the dates from the first holiday of the market to the last.
+func NewFromHolidays(market Market, holidays []models.MarketHoliday) *Calendar {
+	c := &Calendar{
+		market:   market,
+		hours:    marketHours[market],
+		loc:      tz.NewYork(),
+		holidays: make(map[civil.Date]models.MarketHoliday),
+	}
+	c.merge(holidays)
+	return c
+}
+
+// Refresh adds the upcoming holidays from the API to the calendar, replacing bundled holidays of the same dates. The
+// covered range is extended to the last holiday listed.
+func (c *Calendar) Refresh(ctx context.Context, client Getter) error {
+	res, err := client.GetMarketHolidays(ctx)
+	if err != nil {
+		return err
+	}
+	c.merge(*res)
+	return nil
+}
+
+// Market returns the market of the calendar.
+func (c *Calendar) Market() Market {
+	return c.market
+}
+
+// Location returns the exchange time zone.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// Range returns the range of dates that the calendar covers. It's empty if the calendar has no holidays.
+func (c *Calendar) Range() DateRange {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.covered
+}
+
+// Covers reports whether the calendar covers a date.
+func (c *Calendar) Covers(d civil.Date) bool {
+	r := c.Range()
+	return !r.From.IsZero() && !d.Before(r.From) && !d.After(r.To)
+}
+
+// Date returns the date of a time in exchange time.
+func (c *Calendar) Date(t time.Time) civil.Date {
+	return civil.DateOf(t.In(c.loc))
+}
+
+// Day returns a calendar day. It fails with ErrNotCovered if the calendar doesn't cover the date.
+func (c *Calendar) Day(d civil.Date) (Day, error) {
+	if !c.Covers(d) {
+		return Day{}, c.notCovered(d)
+	}
+	c.mtx.RLock()
+	h, ok := c.holidays[d]
+	c.mtx.RUnlock()
+
+	day := Day{Date: d}
+	if wd := d.Weekday(); wd == time.Saturday || wd == time.Sunday {
+		return day, nil
+	}
+	if ok && h.Status == statusClosed {
+		day.Holiday = h.Name
+		return day, nil
+	}
+
+	at := func(offset time.Duration) time.Time {
+		// offsets are wall clock times, which only differ from elapsed times on days the clocks change
+		return time.Date(d.Year, d.Month, d.Day, 0, int(offset.Minutes()), 0, 0, c.loc)
+	}
+	day.Open = true
+	day.PreMarket = Interval{at(c.hours.preMarket), at(c.hours.open)}
+	day.Regular = Interval{at(c.hours.open), at(c.hours.close)}
+	day.AfterHours = Interval{at(c.hours.close), at(c.hours.afterHours)}
+
+	if ok && h.Status == statusEarlyClose {
+		day.EarlyClose = true
+		day.Holiday = h.Name
+		if open := time.Time(h.Open); !open.IsZero() {
+			day.Regular.Start = open.In(c.loc)
+			day.PreMarket.End = day.Regular.Start
+		}
+		if end := time.Time(h.Close); !end.IsZero() {
+			day.Regular.End = end.In(c.loc)
+		} else {
+			day.Regular.End = at(13 * time.Hour)
+		}
+		day.AfterHours = Interval{day.Regular.End, day.Regular.End.Add(c.hours.earlyAfterHours)}
+	}
+	return day, nil
+}
+
+// IsTradingDay reports whether the market trades on a date.
+func (c *Calendar) IsTradingDay(d civil.Date) (bool, error) {
+	day, err := c.Day(d)
+	return day.Open, err
+}
+
+// IsEarlyClose reports whether the regular session closes early on a date.
+func (c *Calendar) IsEarlyClose(d civil.Date) (bool, error) {
+	day, err := c.Day(d)
+	return day.EarlyClose, err
+}
+
+// Next returns the first trading day after a date. It fails with ErrNotCovered if the calendar ends before it.
+func (c *Calendar) Next(d civil.Date) (civil.Date, error) {
+	return c.step(d, 1)
+}
+
+// Previous returns the last trading day before a date. It fails with ErrNotCovered if the calendar starts after it.
+func (c *Calendar) Previous(d civil.Date) (civil.Date, error) {
+	return c.step(d, -1)
+}
+
+func (c *Calendar) step(d civil.Date, days int) (civil.Date, error) {
+	for {
+		d = d.AddDays(days)
+		open, err := c.IsTradingDay(d)
+		if err != nil {
+			return civil.Date{}, err
+		}
+		if open {
+			return d, nil
+		}
+	}
+}
+
+// TradingDays returns the trading days from one date to another, including both. It fails with ErrNotCovered if
+// the calendar doesn't cover all of the dates.
+func (c *Calendar) TradingDays(from, to civil.Date) ([]civil.Date, error) {
+	if !from.After(to) {
+		for _, d := range []civil.Date{from, to} {
+			if !c.Covers(d) {
+				return nil, c.notCovered(d)
+			}
+		}
+	}
+	var out []civil.Date
+	for d := from; !d.After(to); d = d.AddDays(1) {
+		open, err := c.IsTradingDay(d)
+		if err != nil {
+			return nil, err
+		}
+		if open {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+// Count returns the number of trading sessions from one date to another, including both.
+func (c *Calendar) Count(from, to civil.Date) (int, error) {
+	tds, err := c.TradingDays(from, to)
+	return len(tds), err
+}
+
+// Session returns the session that a time falls in.
+func (c *Calendar) Session(t time.Time) (Session, error) {
+	day, err := c.Day(c.Date(t))
+	if err != nil {
+		return Closed, err
+	}
+	for _, s := range []Session{PreMarket, Regular, AfterHours} {
+		if day.Session(s).Contains(t) {
+			return s, nil
+		}
+	}
+	return Closed, nil
+}
+
+// Chunks splits a date range into ranges of at most a number of trading days each, e.g. to keep requests for minute
+// aggregates under the result limit. Each range starts and ends on a trading day, and ranges without any are
+// skipped.
+func (c *Calendar) Chunks(from, to civil.Date, days int) ([]DateRange, error) {
+	tds, err := c.TradingDays(from, to)
+	if err != nil {
+		return nil, err
+	}
+	days = max(days, 1)
+	var out []DateRange
+	for len(tds) > 0 {
+		n := min(days, len(tds))
+		out = append(out, DateRange{From: tds[0], To: tds[n-1]})
+		tds = tds[n:]
+	}
+	return out, nil
+}
+
+// Aggs returns the aggregates that start within a set of sessions, e.g. to resample minute aggregates of the regular
+// session only.
+func (c *Calendar) Aggs(aggs []models.Agg, sessions ...Session) ([]models.Agg, error) {
+	out := make([]models.Agg, 0, len(aggs))
+	for _, agg := range aggs {
+		s, err := c.Session(time.Time(agg.Timestamp))
+		if err != nil {
+			return nil, err
+		}
+		for _, want := range sessions {
+			if s == want {
+				out = append(out, agg)
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func (c *Calendar) notCovered(d civil.Date) error {
+	r := c.Range()
+	if r.From.IsZero() {
+		return fmt.Errorf("%w: %v, the calendar has no holidays", ErrNotCovered, d)
+	}
+	return fmt.Errorf("%w: %v is outside of %v to %v", ErrNotCovered, d, r.From, r.To)
+}
+
+func (c *Calendar) merge(holidays []models.MarketHoliday) {
+	exchange := string(c.market)
+	if c.market == OPRA {
+		exchange = string(NYSE)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, h := range holidays {
+		if h.Exchange != exchange {
+			continue
+		}
+		c.holidays[h.Date] = h
+		if c.covered.From.IsZero() || h.Date.Before(c.covered.From) {
+			c.covered.From = h.Date
+		}
+		if h.Date.After(c.covered.To) {
+			c.covered.To = h.Date
+		}
+	}
+}
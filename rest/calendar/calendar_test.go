@@ -0,0 +1,172 @@
+package calendar_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/calendar"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) civil.Date {
+	d, _ := civil.ParseDate(s)
+	return d
+}
+
+func TestDay(t *testing.T) {
+	cal := calendar.New(calendar.NYSE)
+	ny := cal.Location()
+
+	day, err := cal.Day(date("2024-11-29"))
+	assert.Nil(t, err)
+	assert.True(t, day.Open)
+	assert.True(t, day.EarlyClose)
+	assert.Equal(t, "Thanksgiving", day.Holiday)
+	assert.True(t, time.Date(2024, 11, 29, 9, 30, 0, 0, ny).Equal(day.Regular.Start))
+	assert.True(t, time.Date(2024, 11, 29, 13, 0, 0, 0, ny).Equal(day.Regular.End))
+	assert.True(t, time.Date(2024, 11, 29, 17, 0, 0, 0, ny).Equal(day.AfterHours.End))
+
+	day, err = cal.Day(date("2024-11-28"))
+	assert.Nil(t, err)
+	assert.False(t, day.Open)
+	assert.Equal(t, "Thanksgiving", day.Holiday)
+	assert.True(t, day.Regular.IsZero())
+	open, err := cal.IsTradingDay(date("2024-11-30"))
+	assert.Nil(t, err)
+	assert.False(t, open)
+
+	// boundaries are in exchange time on either side of a clock change
+	day, _ = cal.Day(date("2024-03-11"))
+	assert.Equal(t, time.Date(2024, 3, 11, 13, 30, 0, 0, time.UTC), day.Regular.Start.UTC())
+	assert.Equal(t, time.Date(2024, 3, 11, 8, 0, 0, 0, time.UTC), day.PreMarket.Start.UTC())
+	day, _ = cal.Day(date("2024-03-08"))
+	assert.Equal(t, time.Date(2024, 3, 8, 14, 30, 0, 0, time.UTC), day.Regular.Start.UTC())
+
+	// options have no extended hours
+	day, _ = calendar.New(calendar.OPRA).Day(date("2024-11-29"))
+	assert.True(t, day.EarlyClose)
+	assert.True(t, day.PreMarket.IsZero())
+	assert.True(t, day.AfterHours.IsZero())
+}
+
+func TestRange(t *testing.T) {
+	cal := calendar.New(calendar.NYSE)
+	assert.Equal(t, calendar.DateRange{From: date("2020-01-01"), To: date("2027-12-31")}, cal.Range())
+	assert.True(t, cal.Covers(date("2027-12-31")))
+	assert.False(t, cal.Covers(date("2019-12-31")))
+
+	_, err := cal.Day(date("2028-01-03"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+	_, err = cal.IsTradingDay(date("2019-12-28"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+	_, err = cal.Next(date("2027-12-31"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+	_, err = cal.Previous(date("2020-01-02"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+	_, err = cal.Count(date("2027-12-01"), date("2028-01-31"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+	_, err = cal.Session(time.Date(2028, 1, 3, 12, 0, 0, 0, cal.Location()))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+
+	// calendars from holidays cover the dates from the first holiday to the last
+	cal = calendar.NewFromHolidays(calendar.NYSE, []models.MarketHoliday{
+		{Exchange: "NYSE", Date: date("2024-07-04"), Status: "closed"},
+		{Exchange: "NYSE", Date: date("2024-12-25"), Status: "closed"},
+		{Exchange: "NASDAQ", Date: date("2025-01-01"), Status: "closed"},
+	})
+	assert.Equal(t, calendar.DateRange{From: date("2024-07-04"), To: date("2024-12-25")}, cal.Range())
+	_, err = cal.Day(date("2025-01-01"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+	_, err = calendar.NewFromHolidays(calendar.NYSE, nil).Day(date("2024-07-05"))
+	assert.ErrorIs(t, err, calendar.ErrNotCovered)
+}
+
+func TestTradingDays(t *testing.T) {
+	cal := calendar.New(calendar.Nasdaq)
+	day := func(d civil.Date, err error) civil.Date {
+		assert.Nil(t, err)
+		return d
+	}
+	count := func(n int, err error) int {
+		assert.Nil(t, err)
+		return n
+	}
+
+	assert.Equal(t, date("2024-11-29"), day(cal.Next(date("2024-11-27"))))
+	assert.Equal(t, date("2024-12-02"), day(cal.Next(date("2024-11-29"))))
+	assert.Equal(t, date("2024-12-31"), day(cal.Previous(date("2025-01-02"))))
+	assert.Equal(t, date("2025-01-08"), day(cal.Previous(date("2025-01-10"))))
+	assert.Equal(t, 21, count(cal.Count(date("2024-01-01"), date("2024-01-31"))))
+	assert.Equal(t, 0, count(cal.Count(date("2024-12-25"), date("2024-12-25"))))
+	assert.Equal(t, 1, count(cal.Count(date("2024-12-24"), date("2024-12-25"))))
+
+	ny := cal.Location()
+	session := func(s calendar.Session, err error) calendar.Session {
+		assert.Nil(t, err)
+		return s
+	}
+	assert.Equal(t, calendar.PreMarket, session(cal.Session(time.Date(2024, 11, 29, 9, 29, 0, 0, ny))))
+	assert.Equal(t, calendar.Regular, session(cal.Session(time.Date(2024, 11, 29, 9, 30, 0, 0, ny))))
+	assert.Equal(t, calendar.AfterHours, session(cal.Session(time.Date(2024, 11, 29, 13, 0, 0, 0, ny))))
+	assert.Equal(t, calendar.Closed, session(cal.Session(time.Date(2024, 11, 29, 17, 0, 0, 0, ny))))
+	assert.Equal(t, calendar.Closed, session(cal.Session(time.Date(2024, 11, 28, 12, 0, 0, 0, ny))))
+	assert.Equal(t, "after-hours", calendar.AfterHours.String())
+}
+
+func TestChunksAndAggs(t *testing.T) {
+	cal := calendar.New(calendar.NYSE)
+
+	chunks, err := cal.Chunks(date("2024-11-23"), date("2024-12-04"), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []calendar.DateRange{
+		{From: date("2024-11-25"), To: date("2024-11-27")},
+		{From: date("2024-11-29"), To: date("2024-12-03")},
+		{From: date("2024-12-04"), To: date("2024-12-04")},
+	}, chunks)
+	chunks, err = cal.Chunks(date("2024-11-30"), date("2024-12-01"), 3)
+	assert.Nil(t, err)
+	assert.Empty(t, chunks)
+
+	ny := cal.Location()
+	agg := func(h, m int) models.Agg {
+		return models.Agg{Timestamp: models.Millis(time.Date(2024, 11, 29, h, m, 0, 0, ny))}
+	}
+	aggs := []models.Agg{agg(8, 0), agg(9, 30), agg(12, 59), agg(13, 0), agg(18, 0)}
+	regular, err := cal.Aggs(aggs, calendar.Regular)
+	assert.Nil(t, err)
+	assert.Equal(t, aggs[1:3], regular)
+	extended, err := cal.Aggs(aggs, calendar.PreMarket, calendar.Regular, calendar.AfterHours)
+	assert.Nil(t, err)
+	assert.Equal(t, aggs[:4], extended)
+}
+
+func TestRefresh(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	nyse, nasdaq := calendar.New(calendar.NYSE), calendar.New(calendar.Nasdaq)
+	assert.False(t, nyse.Covers(date("2028-07-04")))
+	assert.Nil(t, nyse.Refresh(context.Background(), s.Client("API_KEY")))
+	assert.Nil(t, nasdaq.Refresh(context.Background(), s.Client("API_KEY")))
+	assert.Equal(t, date("2028-07-04"), nyse.Range().To)
+
+	nyseDay, err := nyse.Day(date("2028-07-04"))
+	assert.Nil(t, err)
+	assert.False(t, nyseDay.Open)
+	nyseDay, _ = nyse.Day(date("2028-07-03"))
+	assert.True(t, nyseDay.EarlyClose)
+	nasdaqDay, err := nasdaq.Day(date("2028-07-04"))
+	assert.Nil(t, err)
+	assert.False(t, nasdaqDay.Open)
+	nasdaqDay, _ = nasdaq.Day(date("2028-07-03"))
+	assert.False(t, nasdaqDay.EarlyClose)
+
+	// bundled holidays are kept
+	open, err := nyse.IsTradingDay(date("2024-11-28"))
+	assert.Nil(t, err)
+	assert.False(t, open)
+}
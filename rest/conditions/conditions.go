@@ -0,0 +1,173 @@
+// Package conditions decodes the condition codes of trades and quotes.
+//
+// Trades and quotes only carry numeric condition codes. A Table maps them to the conditions returned by
+// ReferenceClient.ListConditions and answers whether a trade should update the last price, the high and low, or the
+// volume of an aggregate according to the update rules of its conditions:
+//
+//	tbl, err := conditions.Load(ctx, c, models.AssetStocks)
+//	if err != nil {
+//		tbl = conditions.Default()
+//	}
+//	if tbl.EquityTrade(trade).OpenClose {
+//		last = trade.Price
+//	}
+//
+// Default returns a table that is bundled with the package and works offline. It only covers the sale conditions of
+// stock trades, so Load should be preferred where the API can be reached.
+package conditions
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/polygon-io/client-go/rest/iter"
+	"github.com/polygon-io/client-go/rest/models"
+	wsmodels "github.com/polygon-io/client-go/websocket/models"
+)
+
+//go:embed default.json
+var defaultConditions []byte
+
+// Lister lists conditions. The Client of the rest package implements it.
+type Lister interface {
+	ListConditions(ctx context.Context, params *models.ListConditionsParams, options ...models.RequestOption) *iter.Iter[models.Condition]
+}
+
+// Updates is what a trade updates in an aggregate.
+type Updates struct {
+	// HighLow is whether the trade price can set the high or low.
+	HighLow bool
+
+	// OpenClose is whether the trade price can set the open or close, i.e. whether it's a last sale.
+	OpenClose bool
+
+	// Volume is whether the trade size counts towards the volume.
+	Volume bool
+}
+
+// Table maps condition codes to conditions. Codes are only unique per asset class and data type, so every lookup
+// takes both. It's safe for concurrent use once built.
+type Table struct {
+	conditions map[key]models.Condition
+}
+
+type key struct {
+	asset    models.AssetClass
+	dataType models.DataType
+	id       int32
+}
+
+// NewTable builds a table from a list of conditions.
+func NewTable(conditions []models.Condition) *Table {
+	t := &Table{conditions: make(map[key]models.Condition)}
+	for _, c := range conditions {
+		for _, dt := range c.DataTypes {
+			t.conditions[key{models.AssetClass(c.AssetClass), models.DataType(dt), int32(c.ID)}] = c
+		}
+	}
+	return t
+}
+
+// Load builds a table from every condition of an asset class.
+func Load(ctx context.Context, client Lister, asset models.AssetClass) (*Table, error) {
+	it := client.ListConditions(ctx, models.ListConditionsParams{}.WithAssetClass(asset).WithLimit(1000))
+	var conditions []models.Condition
+	for it.Next() {
+		conditions = append(conditions, it.Item())
+	}
+	if it.Err() != nil {
+		return nil, it.Err()
+	}
+	return NewTable(conditions), nil
+}
+
+// Default returns the table bundled with the package, which covers the sale conditions of stock trades. The table is
+// parsed once and shared, so it must be treated as read-only.
+func Default() *Table {
+	return defaultOnce()
+}
+
+var defaultOnce = sync.OnceValue(func() *Table {
+	var res models.ListConditionsResponse
+	if err := json.Unmarshal(defaultConditions, &res); err != nil {
+		panic(fmt.Sprintf("invalid bundled conditions: %v", err))
+	}
+	return NewTable(res.Results)
+})
+
+// Condition returns the condition of a code.
+func (t *Table) Condition(asset models.AssetClass, dataType models.DataType, id int32) (models.Condition, bool) {
+	c, ok := t.conditions[key{asset, dataType, id}]
+	return c, ok
+}
+
+// Conditions returns the conditions of a list of codes, skipping unknown codes.
+func (t *Table) Conditions(asset models.AssetClass, dataType models.DataType, ids []int32) []models.Condition {
+	out := make([]models.Condition, 0, len(ids))
+	for _, id := range ids {
+		if c, ok := t.Condition(asset, dataType, id); ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Updates returns what a trade with a list of conditions updates in a consolidated aggregate. A trade only updates
+// what every one of its conditions allows, and a trade without conditions is a regular sale that updates everything.
+// Unknown codes don't restrict anything.
+func (t *Table) Updates(asset models.AssetClass, ids []int32) Updates {
+	return t.updates(asset, ids, func(c models.Condition) Updates {
+		r := c.UpdateRules.Consolidated
+		return Updates{HighLow: r.UpdatesHighLow, OpenClose: r.UpdatesOpenClose, Volume: r.UpdatesVolume}
+	})
+}
+
+// MarketCenterUpdates returns what a trade with a list of conditions updates in the aggregate of the exchange that
+// reported it.
+func (t *Table) MarketCenterUpdates(asset models.AssetClass, ids []int32) Updates {
+	return t.updates(asset, ids, func(c models.Condition) Updates {
+		r := c.UpdateRules.MarketCenter
+		return Updates{HighLow: r.UpdatesHighLow, OpenClose: r.UpdatesOpenClose, Volume: r.UpdatesVolume}
+	})
+}
+
+// EquityTrade returns what a stock trade from the WebSocket API updates in a consolidated aggregate.
+func (t *Table) EquityTrade(trade wsmodels.EquityTrade) Updates {
+	return t.Updates(models.AssetStocks, trade.Conditions)
+}
+
+// Trade returns what a trade from the REST API updates in a consolidated aggregate.
+func (t *Table) Trade(asset models.AssetClass, trade models.Trade) Updates {
+	return t.Updates(asset, trade.Conditions)
+}
+
+// EquityQuote returns the conditions of a stock quote from the WebSocket API.
+func (t *Table) EquityQuote(quote wsmodels.EquityQuote) []models.Condition {
+	if quote.Condition == 0 {
+		return nil
+	}
+	return t.Conditions(models.AssetStocks, models.DataNBBO, []int32{quote.Condition})
+}
+
+// Quote returns the conditions of a quote from the REST API.
+func (t *Table) Quote(asset models.AssetClass, quote models.Quote) []models.Condition {
+	return t.Conditions(asset, models.DataNBBO, quote.Conditions)
+}
+
+func (t *Table) updates(asset models.AssetClass, ids []int32, rules func(models.Condition) Updates) Updates {
+	u := Updates{HighLow: true, OpenClose: true, Volume: true}
+	for _, id := range ids {
+		c, ok := t.Condition(asset, models.DataTrade, id)
+		if !ok {
+			continue
+		}
+		r := rules(c)
+		u.HighLow = u.HighLow && r.HighLow
+		u.OpenClose = u.OpenClose && r.OpenClose
+		u.Volume = u.Volume && r.Volume
+	}
+	return u
+}
@@ -0,0 +1,56 @@
+package conditions_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polygon-io/client-go/rest/conditions"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	wsmodels "github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	tbl := conditions.Default()
+	assert.Same(t, tbl, conditions.Default()) // parsed once
+
+	c, ok := tbl.Condition(models.AssetStocks, models.DataTrade, 37)
+	assert.True(t, ok)
+	assert.Equal(t, "Odd Lot Trade", c.Name)
+
+	// a regular sale updates everything
+	all := conditions.Updates{HighLow: true, OpenClose: true, Volume: true}
+	assert.Equal(t, all, tbl.EquityTrade(wsmodels.EquityTrade{Price: 100, Size: 100}))
+	assert.Equal(t, all, tbl.Updates(models.AssetStocks, []int32{14}))
+
+	// odd lots and out of sequence trades only count towards the volume
+	assert.Equal(t, conditions.Updates{Volume: true}, tbl.EquityTrade(wsmodels.EquityTrade{Conditions: []int32{37}}))
+	assert.Equal(t, conditions.Updates{HighLow: true, Volume: true}, tbl.Trade(models.AssetStocks, models.Trade{Conditions: []int32{14, 32}}))
+
+	// unknown codes and other asset classes don't restrict anything
+	assert.Equal(t, all, tbl.Updates(models.AssetStocks, []int32{9999}))
+	assert.Equal(t, all, tbl.Updates(models.AssetOptions, []int32{37}))
+	assert.Empty(t, tbl.Conditions(models.AssetStocks, models.DataTrade, []int32{9999}))
+}
+
+func TestLoad(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	tbl, err := conditions.Load(context.Background(), s.Client("API_KEY"), models.AssetStocks)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v3/reference/conditions?asset_class=stocks&limit=1000"}, s.Requests())
+
+	// the official close only updates the close of the market center that reported it
+	assert.Equal(t, conditions.Updates{}, tbl.Updates(models.AssetStocks, []int32{15}))
+	assert.Equal(t, conditions.Updates{OpenClose: true}, tbl.MarketCenterUpdates(models.AssetStocks, []int32{15}))
+
+	// codes of trades and quotes don't collide
+	_, ok := tbl.Condition(models.AssetStocks, models.DataTrade, 1)
+	assert.False(t, ok)
+	quote := tbl.EquityQuote(wsmodels.EquityQuote{Condition: 1})
+	assert.Len(t, quote, 1)
+	assert.Equal(t, "Regular, Two-Sided Open", quote[0].Name)
+	assert.Len(t, tbl.Quote(models.AssetStocks, models.Quote{Conditions: []int32{1, 2}}), 1)
+}
@@ -0,0 +1,248 @@
+// Package dividends analyzes the dividends returned by ReferenceClient.ListDividends.
+//
+// It builds ex-dividend calendars across watchlists, computes trailing and forward yields, tells special dividends
+// from regular ones, checks that payouts follow their declared frequency, and computes total return series that
+// reinvest dividends:
+//
+//	upcoming, err := dividends.Upcoming(ctx, c, []string{"AAPL", "KO", "O"}, today)
+//	if err != nil {
+//		return err
+//	}
+//	for _, d := range upcoming {
+//		log.Print(d.Ticker, d.ExDividendDate, d.CashAmount)
+//	}
+//	yield := dividends.TrailingYield(history, today, price)
+//
+// Yields are undefined and NaN without a positive price or, for forward yields, without a regular dividend.
+package dividends
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/iter"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// Lister lists dividends. The Client of the rest package implements it.
+type Lister interface {
+	ListDividends(ctx context.Context, params *models.ListDividendsParams, options ...models.RequestOption) *iter.Iter[models.Dividend]
+}
+
+// Upcoming lists the dividends of a watchlist that go ex on or after a date, sorted by ex-dividend date and ticker.
+func Upcoming(ctx context.Context, client Lister, tickers []string, from civil.Date) ([]models.Dividend, error) {
+	var out []models.Dividend
+	for _, ticker := range tickers {
+		it := client.ListDividends(ctx, models.ListDividendsParams{}.
+			WithTicker(models.EQ, ticker).
+			WithExDividendDate(models.GTE, from).
+			WithLimit(1000))
+		for it.Next() {
+			out = append(out, it.Item())
+		}
+		if it.Err() != nil {
+			return nil, it.Err()
+		}
+	}
+	return Calendar(out, from, civil.Date{}), nil
+}
+
+// Calendar returns the dividends that go ex from one date to another, including both, sorted by ex-dividend date and
+// ticker. A zero end date has no upper bound.
+func Calendar(dividends []models.Dividend, from, to civil.Date) []models.Dividend {
+	var out []models.Dividend
+	for _, d := range dividends {
+		if d.ExDividendDate.Before(from) || (!to.IsZero() && d.ExDividendDate.After(to)) {
+			continue
+		}
+		out = append(out, d)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if c := out[i].ExDividendDate.Compare(out[j].ExDividendDate); c != 0 {
+			return c < 0
+		}
+		return out[i].Ticker < out[j].Ticker
+	})
+	return out
+}
+
+// IsSpecial reports whether a dividend is a special cash dividend, which isn't expected to recur.
+func IsSpecial(d models.Dividend) bool {
+	return models.DividendType(d.DividendType) == models.DividendSC
+}
+
+// IsRegular reports whether a dividend is a regular cash dividend. Capital gain distributions of funds are neither
+// regular nor special.
+func IsRegular(d models.Dividend) bool {
+	return models.DividendType(d.DividendType) == models.DividendCD
+}
+
+// TrailingYield returns the regular dividends that went ex in the year up to and including a date, relative to a
+// price.
+func TrailingYield(dividends []models.Dividend, asOf civil.Date, price float64) float64 {
+	if price <= 0 {
+		return math.NaN()
+	}
+	start := asOf.AddYears(-1)
+	var total float64
+	for _, d := range dividends {
+		if IsRegular(d) && d.ExDividendDate.After(start) && !d.ExDividendDate.After(asOf) {
+			total += d.CashAmount
+		}
+	}
+	return total / price
+}
+
+// ForwardYield returns the latest regular dividend that was declared by a date, annualized by its frequency and
+// relative to a price. Dividends without a declaration date count as declared on their ex-dividend date.
+func ForwardYield(dividends []models.Dividend, asOf civil.Date, price float64) float64 {
+	var latest *models.Dividend
+	for i, d := range dividends {
+		if !IsRegular(d) || d.Frequency <= 0 || declared(d).After(asOf) {
+			continue
+		}
+		if latest == nil || d.ExDividendDate.After(latest.ExDividendDate) {
+			latest = &dividends[i]
+		}
+	}
+	if latest == nil || price <= 0 {
+		return math.NaN()
+	}
+	return latest.CashAmount * float64(latest.Frequency) / price
+}
+
+// IrregularityKind is the way a payout deviates from its declared frequency.
+type IrregularityKind string
+
+const (
+	// Missed is a gap between ex-dividend dates of more than one and a half periods, i.e. at least one payout is
+	// missing.
+	Missed IrregularityKind = "missed"
+
+	// Extra is a gap between ex-dividend dates of less than half a period.
+	Extra IrregularityKind = "extra"
+
+	// FrequencyChanged is a dividend with a different frequency than the previous one.
+	FrequencyChanged IrregularityKind = "frequency_changed"
+)
+
+// Irregularity is a regular dividend that doesn't follow the frequency declared by the previous one of its ticker.
+type Irregularity struct {
+	Kind     IrregularityKind
+	Dividend models.Dividend
+	Previous models.Dividend
+
+	// Gap is the number of days between the ex-dividend dates.
+	Gap int
+
+	// Expected is the number of days between ex-dividend dates implied by the declared frequency.
+	Expected int
+}
+
+// Validate checks the cadence of the regular dividends of every ticker against their declared frequency and returns
+// the irregularities in order of ex-dividend date.
+func Validate(dividends []models.Dividend) []Irregularity {
+	byTicker := make(map[string][]models.Dividend)
+	for _, d := range dividends {
+		if IsRegular(d) && d.Frequency > 0 {
+			byTicker[d.Ticker] = append(byTicker[d.Ticker], d)
+		}
+	}
+
+	var out []Irregularity
+	for _, ds := range byTicker {
+		ds = Calendar(ds, civil.Date{}, civil.Date{})
+		for i := 1; i < len(ds); i++ {
+			prev, d := ds[i-1], ds[i]
+			irr := Irregularity{
+				Dividend: d,
+				Previous: prev,
+				Gap:      d.ExDividendDate.DaysSince(prev.ExDividendDate),
+				Expected: int(math.Round(365.25 / float64(prev.Frequency))),
+			}
+			switch {
+			case d.Frequency != prev.Frequency:
+				irr.Kind = FrequencyChanged
+			case float64(irr.Gap) > 1.5*float64(irr.Expected):
+				irr.Kind = Missed
+			case float64(irr.Gap) < 0.5*float64(irr.Expected):
+				irr.Kind = Extra
+			default:
+				continue
+			}
+			out = append(out, irr)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if c := out[i].Dividend.ExDividendDate.Compare(out[j].Dividend.ExDividendDate); c != 0 {
+			return c < 0
+		}
+		return out[i].Dividend.Ticker < out[j].Dividend.Ticker
+	})
+	return out
+}
+
+// Point is a bar of a total return series.
+type Point struct {
+	Timestamp models.Millis
+	Close     float64
+
+	// Dividend is the cash paid per share by the dividends that went ex since the previous bar.
+	Dividend float64
+
+	// Return is the total return since the previous bar, which is zero for the first bar.
+	Return float64
+
+	// Index is the value of one unit of currency invested at the first close with dividends reinvested.
+	Index float64
+}
+
+// TotalReturn computes the total return series of unadjusted aggregates of a ticker, reinvesting every cash dividend
+// on its ex-dividend date. Splits are applied on their execution date so that the returns don't jump, and can be nil
+// if the aggregates are already split adjusted and the dividend amounts are in the same basis.
+func TotalReturn(aggs []models.Agg, dividends []models.Dividend, splits []models.Split) []Point {
+	loc := tz.NewYork()
+	out := make([]Point, 0, len(aggs))
+	var prev civil.Date
+	for i, agg := range aggs {
+		date := civil.DateOf(time.Time(agg.Timestamp).In(loc))
+		p := Point{Timestamp: agg.Timestamp, Close: agg.Close, Index: 1}
+		if i == 0 {
+			out = append(out, p)
+			prev = date
+			continue
+		}
+
+		// the previous close in the share basis of this bar
+		base := aggs[i-1].Close
+		for _, s := range splits {
+			if s.SplitFrom > 0 && s.SplitTo > 0 && s.ExecutionDate.After(prev) && !s.ExecutionDate.After(date) {
+				base *= s.SplitFrom / s.SplitTo
+			}
+		}
+		for _, d := range dividends {
+			if d.CashAmount > 0 && d.ExDividendDate.After(prev) && !d.ExDividendDate.After(date) {
+				p.Dividend += d.CashAmount
+			}
+		}
+		if base > 0 {
+			p.Return = (agg.Close+p.Dividend)/base - 1
+		}
+		p.Index = out[i-1].Index * (1 + p.Return)
+		out = append(out, p)
+		prev = date
+	}
+	return out
+}
+
+// declared returns the date a dividend was declared, or its ex-dividend date if that's unknown.
+func declared(d models.Dividend) civil.Date {
+	if d.DeclarationDate.IsZero() {
+		return d.ExDividendDate
+	}
+	return d.DeclarationDate
+}
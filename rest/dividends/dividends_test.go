@@ -0,0 +1,106 @@
+package dividends_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/dividends"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) civil.Date {
+	d, _ := civil.ParseDate(s)
+	return d
+}
+
+func dividend(ticker, ex string, amount float64, frequency int64, typ models.DividendType) models.Dividend {
+	return models.Dividend{Ticker: ticker, ExDividendDate: date(ex), CashAmount: amount, Frequency: frequency, DividendType: string(typ)}
+}
+
+func TestUpcoming(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	upcoming, err := dividends.Upcoming(context.Background(), s.Client("API_KEY"), []string{"KO", "O"}, date("2024-06-01"))
+	assert.Nil(t, err)
+	assert.Len(t, s.Requests(), 2)
+
+	var got []string
+	for _, d := range upcoming {
+		got = append(got, d.ExDividendDate.String()+" "+d.Ticker)
+	}
+	assert.Equal(t, []string{"2024-06-14 KO", "2024-06-14 O", "2024-07-01 O", "2024-09-13 KO"}, got)
+	assert.Len(t, dividends.Calendar(upcoming, date("2024-06-15"), date("2024-07-31")), 1)
+}
+
+func TestYields(t *testing.T) {
+	history := []models.Dividend{
+		dividend("KO", "2023-06-14", 0.46, 4, models.DividendCD),
+		dividend("KO", "2023-09-14", 0.46, 4, models.DividendCD),
+		dividend("KO", "2023-11-30", 0.46, 4, models.DividendCD),
+		dividend("KO", "2024-03-14", 0.485, 4, models.DividendCD),
+		dividend("KO", "2024-04-01", 1.00, 0, models.DividendSC),
+	}
+	history[3].DeclarationDate = date("2024-02-15")
+
+	assert.True(t, dividends.IsSpecial(history[4]))
+	assert.False(t, dividends.IsRegular(history[4]))
+
+	// the dividend of 2023-06-14 is more than a year old, and specials are excluded
+	assert.InDelta(t, (0.46+0.46+0.485)/50, dividends.TrailingYield(history, date("2024-06-14"), 50), 1e-12)
+
+	// the forward yield uses the latest dividend that was declared
+	assert.InDelta(t, 0.46*4/50, dividends.ForwardYield(history, date("2024-02-01"), 50), 1e-12)
+	assert.InDelta(t, 0.485*4/50, dividends.ForwardYield(history, date("2024-02-15"), 50), 1e-12)
+	assert.True(t, math.IsNaN(dividends.ForwardYield(history, date("2023-01-01"), 50)))
+	assert.True(t, math.IsNaN(dividends.TrailingYield(history, date("2024-06-14"), 0)))
+}
+
+func TestValidate(t *testing.T) {
+	irr := dividends.Validate([]models.Dividend{
+		dividend("KO", "2023-03-14", 0.46, 4, models.DividendCD),
+		dividend("KO", "2023-06-14", 0.46, 4, models.DividendCD),
+		dividend("KO", "2023-12-14", 0.46, 4, models.DividendCD),
+		dividend("KO", "2023-12-28", 0.46, 4, models.DividendCD),
+		dividend("KO", "2023-12-29", 1.00, 0, models.DividendSC),
+		dividend("O", "2023-05-31", 0.25, 12, models.DividendCD),
+		dividend("O", "2023-06-30", 0.25, 12, models.DividendCD),
+		dividend("O", "2023-09-29", 0.75, 4, models.DividendCD),
+	})
+
+	assert.Len(t, irr, 3)
+	assert.Equal(t, dividends.FrequencyChanged, irr[0].Kind)
+	assert.Equal(t, "O", irr[0].Dividend.Ticker)
+	assert.Equal(t, dividends.Missed, irr[1].Kind)
+	assert.Equal(t, 183, irr[1].Gap)
+	assert.Equal(t, 91, irr[1].Expected)
+	assert.Equal(t, dividends.Extra, irr[2].Kind)
+	assert.Equal(t, date("2023-12-14"), irr[2].Previous.ExDividendDate)
+}
+
+func TestTotalReturn(t *testing.T) {
+	ny, _ := time.LoadLocation("America/New_York")
+	agg := func(day int, close float64) models.Agg {
+		return models.Agg{Timestamp: models.Millis(time.Date(2024, 6, day, 0, 0, 0, 0, ny)), Close: close}
+	}
+	aggs := []models.Agg{agg(10, 100), agg(11, 101), agg(12, 99), agg(13, 50), agg(14, 51)}
+
+	points := dividends.TotalReturn(aggs,
+		[]models.Dividend{{ExDividendDate: date("2024-06-12"), CashAmount: 2}},
+		[]models.Split{{ExecutionDate: date("2024-06-13"), SplitFrom: 1, SplitTo: 2}},
+	)
+	assert.Len(t, points, 5)
+	assert.Equal(t, 1.0, points[0].Index)
+	assert.InDelta(t, 0.01, points[1].Return, 1e-12)
+
+	// the dividend offsets the drop on the ex-date, and the split doesn't count as a loss
+	assert.Equal(t, 2.0, points[2].Dividend)
+	assert.InDelta(t, 0, points[2].Return, 1e-12)
+	assert.InDelta(t, 1.0/99, points[3].Return, 1e-12)
+	assert.InDelta(t, 1.01*(1+1.0/99)*1.02, points[4].Index, 1e-12)
+}
@@ -0,0 +1,214 @@
+// Package exchanges resolves the exchange IDs of trades and quotes.
+//
+// Trades and quotes only identify venues by numeric IDs. A Directory maps them to the exchanges returned by
+// ReferenceClient.GetExchanges, including the trade reporting facilities (TRFs) that off-exchange trades are reported
+// to:
+//
+//	dir, err := exchanges.Load(ctx, c)
+//	if err != nil {
+//		dir = exchanges.Default()
+//	}
+//	log.Print(dir.Name(models.AssetStocks, trade.Exchange), dir.MIC(models.AssetStocks, trade.Exchange))
+//
+// The trade and quote models of both the REST and the WebSocket clients can also be resolved against any Resolver,
+// such as the exchanges of an asset class:
+//
+//	stocks := dir.Asset(models.AssetStocks)
+//	bid, ask := exchanges.QuoteVenues(stocks, quote)
+//	log.Print(exchanges.TradeVenue(stocks, trade).Name, bid.MIC, ask.MIC)
+//
+// Default returns a directory that is bundled with the package and works offline. It only covers stock exchanges, so
+// Load should be preferred where the API can be reached.
+package exchanges
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/polygon-io/client-go/rest/models"
+	wsmodels "github.com/polygon-io/client-go/websocket/models"
+)
+
+//go:embed default.json
+var defaultExchanges []byte
+
+// typeTRF is the type of trade reporting facilities.
+const typeTRF = "TRF"
+
+// Getter gets exchanges. The Client of the rest package implements it.
+type Getter interface {
+	GetExchanges(ctx context.Context, params *models.GetExchangesParams, options ...models.RequestOption) (*models.GetExchangesResponse, error)
+}
+
+// Directory maps exchange IDs to exchanges. IDs are only unique per asset class, so every lookup takes one. It's
+// safe for concurrent use once built.
+type Directory struct {
+	exchanges map[key]models.Exchange
+}
+
+type key struct {
+	asset models.AssetClass
+	id    int64
+}
+
+// NewDirectory builds a directory from a list of exchanges.
+func NewDirectory(exchanges []models.Exchange) *Directory {
+	d := &Directory{exchanges: make(map[key]models.Exchange, len(exchanges))}
+	for _, e := range exchanges {
+		d.exchanges[key{models.AssetClass(e.AssetClass), e.ID}] = e
+	}
+	return d
+}
+
+// Load builds a directory from the exchanges of every asset class.
+func Load(ctx context.Context, client Getter) (*Directory, error) {
+	res, err := client.GetExchanges(ctx, &models.GetExchangesParams{})
+	if err != nil {
+		return nil, err
+	}
+	return NewDirectory(res.Results), nil
+}
+
+// Default returns the directory bundled with the package, which covers stock exchanges and TRFs. The directory is
+// parsed once and shared, so it must be treated as read-only.
+func Default() *Directory {
+	return defaultOnce()
+}
+
+var defaultOnce = sync.OnceValue(func() *Directory {
+	var res models.GetExchangesResponse
+	if err := json.Unmarshal(defaultExchanges, &res); err != nil {
+		panic(fmt.Sprintf("invalid bundled exchanges: %v", err))
+	}
+	return NewDirectory(res.Results)
+})
+
+// Exchange returns the exchange of an ID.
+func (d *Directory) Exchange(asset models.AssetClass, id int) (models.Exchange, bool) {
+	e, ok := d.exchanges[key{asset, int64(id)}]
+	return e, ok
+}
+
+// Name returns the name of an exchange, or an empty string if the ID is unknown.
+func (d *Directory) Name(asset models.AssetClass, id int) string {
+	e, _ := d.Exchange(asset, id)
+	return e.Name
+}
+
+// MIC returns the market identifier code of an exchange, or an empty string if the ID is unknown or the exchange
+// has none (e.g. the securities information processors).
+func (d *Directory) MIC(asset models.AssetClass, id int) string {
+	e, _ := d.Exchange(asset, id)
+	return e.MIC
+}
+
+// Asset returns the exchanges of an asset class, which resolve the exchange IDs of trade and quote models.
+func (d *Directory) Asset(asset models.AssetClass) AssetDirectory {
+	return AssetDirectory{d: d, asset: asset}
+}
+
+// Resolver resolves the exchange IDs of an asset class to names and market identifier codes. Both are empty for
+// unknown IDs.
+type Resolver interface {
+	Name(id int) string
+	MIC(id int) string
+}
+
+// Venue is the name and market identifier code of a resolved exchange ID.
+type Venue struct {
+	Name string
+	MIC  string
+}
+
+// Resolve resolves an exchange ID.
+func Resolve(r Resolver, id int) Venue {
+	return Venue{Name: r.Name(id), MIC: r.MIC(id)}
+}
+
+// TradeVenue resolves the exchange of a trade from the REST API.
+func TradeVenue(r Resolver, trade models.Trade) Venue {
+	return Resolve(r, trade.Exchange)
+}
+
+// QuoteVenues resolves the bid and ask exchanges of a quote from the REST API.
+func QuoteVenues(r Resolver, quote models.Quote) (bid, ask Venue) {
+	return Resolve(r, quote.BidExchange), Resolve(r, quote.AskExchange)
+}
+
+// EquityTradeVenue resolves the exchange of a stock trade from the WebSocket API.
+func EquityTradeVenue(r Resolver, trade wsmodels.EquityTrade) Venue {
+	return Resolve(r, int(trade.Exchange))
+}
+
+// EquityQuoteVenues resolves the bid and ask exchanges of a stock quote from the WebSocket API.
+func EquityQuoteVenues(r Resolver, quote wsmodels.EquityQuote) (bid, ask Venue) {
+	return Resolve(r, int(quote.BidExchangeID)), Resolve(r, int(quote.AskExchangeID))
+}
+
+// AssetDirectory is the part of a directory that covers an asset class. It implements Resolver.
+type AssetDirectory struct {
+	d     *Directory
+	asset models.AssetClass
+}
+
+// Name returns the name of an exchange, or an empty string if the ID is unknown.
+func (a AssetDirectory) Name(id int) string {
+	return a.d.Name(a.asset, id)
+}
+
+// MIC returns the market identifier code of an exchange, or an empty string if the ID is unknown or the exchange has
+// none.
+func (a AssetDirectory) MIC(id int) string {
+	return a.d.MIC(a.asset, id)
+}
+
+// TRF returns the trade reporting facility of a TRF ID. Only stock trades are reported to TRFs.
+func (d *Directory) TRF(id int) (models.Exchange, bool) {
+	e, ok := d.Exchange(models.AssetStocks, id)
+	if !ok || e.Type != typeTRF {
+		return models.Exchange{}, false
+	}
+	return e, true
+}
+
+// Trade returns the exchange of a trade from the REST API.
+func (d *Directory) Trade(asset models.AssetClass, trade models.Trade) (models.Exchange, bool) {
+	return d.Exchange(asset, trade.Exchange)
+}
+
+// TradeTRF returns the facility that a stock trade from the REST API was reported to. Trades that were executed on
+// an exchange have none.
+func (d *Directory) TradeTRF(trade models.Trade) (models.Exchange, bool) {
+	if trade.TrfID == 0 {
+		return models.Exchange{}, false
+	}
+	return d.TRF(trade.TrfID)
+}
+
+// Quote returns the bid and ask exchanges of a quote from the REST API. Either is empty if its ID is unknown.
+func (d *Directory) Quote(asset models.AssetClass, quote models.Quote) (bid, ask models.Exchange) {
+	bid, _ = d.Exchange(asset, quote.BidExchange)
+	ask, _ = d.Exchange(asset, quote.AskExchange)
+	return bid, ask
+}
+
+// LastTrade returns the exchange of the last trade of a ticker snapshot.
+func (d *Directory) LastTrade(asset models.AssetClass, trade models.LastTradeSnapshot) (models.Exchange, bool) {
+	return d.Exchange(asset, trade.ExchangeID)
+}
+
+// EquityTrade returns the exchange of a stock trade from the WebSocket API.
+func (d *Directory) EquityTrade(trade wsmodels.EquityTrade) (models.Exchange, bool) {
+	return d.Exchange(models.AssetStocks, int(trade.Exchange))
+}
+
+// EquityQuote returns the bid and ask exchanges of a stock quote from the WebSocket API. Either is empty if its ID
+// is unknown.
+func (d *Directory) EquityQuote(quote wsmodels.EquityQuote) (bid, ask models.Exchange) {
+	bid, _ = d.Exchange(models.AssetStocks, int(quote.BidExchangeID))
+	ask, _ = d.Exchange(models.AssetStocks, int(quote.AskExchangeID))
+	return bid, ask
+}
@@ -0,0 +1,79 @@
+package exchanges_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polygon-io/client-go/rest/exchanges"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	wsmodels "github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	dir := exchanges.Default()
+	assert.Same(t, dir, exchanges.Default()) // parsed once
+
+	assert.Equal(t, "New York Stock Exchange", dir.Name(models.AssetStocks, 10))
+	assert.Equal(t, "XNAS", dir.MIC(models.AssetStocks, 12))
+	assert.Equal(t, "", dir.Name(models.AssetStocks, 999))
+
+	e, ok := dir.EquityTrade(wsmodels.EquityTrade{Exchange: 11})
+	assert.True(t, ok)
+	assert.Equal(t, "P", e.ParticipantID)
+
+	bid, ask := dir.EquityQuote(wsmodels.EquityQuote{BidExchangeID: 15, AskExchangeID: 999})
+	assert.Equal(t, "IEXG", bid.MIC)
+	assert.Equal(t, models.Exchange{}, ask)
+
+	// off-exchange trades are reported with the ID of the FINRA ADF and the ID of the facility
+	trade := models.Trade{Exchange: 4, TrfID: 201}
+	e, _ = dir.Trade(models.AssetStocks, trade)
+	assert.Equal(t, "FINRA Alternative Display Facility", e.Name)
+	e, ok = dir.TradeTRF(trade)
+	assert.True(t, ok)
+	assert.Equal(t, "FINY", e.MIC)
+	_, ok = dir.TradeTRF(models.Trade{Exchange: 10})
+	assert.False(t, ok)
+	_, ok = dir.TRF(10)
+	assert.False(t, ok)
+}
+
+func TestVenues(t *testing.T) {
+	stocks := exchanges.Default().Asset(models.AssetStocks)
+
+	trade := models.Trade{Exchange: 10}
+	assert.Equal(t, exchanges.Venue{Name: "New York Stock Exchange", MIC: "XNYS"}, exchanges.TradeVenue(stocks, trade))
+	bid, ask := exchanges.QuoteVenues(stocks, models.Quote{BidExchange: 12, AskExchange: 999})
+	assert.Equal(t, "XNAS", bid.MIC)
+	assert.Equal(t, exchanges.Venue{}, ask)
+
+	assert.Equal(t, "IEXG", exchanges.EquityTradeVenue(stocks, wsmodels.EquityTrade{Exchange: 15}).MIC)
+	bid, ask = exchanges.EquityQuoteVenues(stocks, wsmodels.EquityQuote{BidExchangeID: 11, AskExchangeID: 12})
+	assert.Equal(t, "NYSE Arca, Inc.", bid.Name)
+	assert.Equal(t, "XNAS", ask.MIC)
+
+	// IDs are resolved per asset class
+	assert.Equal(t, exchanges.Venue{}, exchanges.TradeVenue(exchanges.Default().Asset(models.AssetCrypto), trade))
+}
+
+func TestLoad(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	dir, err := exchanges.Load(context.Background(), s.Client("API_KEY"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"/v3/reference/exchanges"}, s.Requests())
+
+	// IDs are resolved per asset class
+	assert.Equal(t, "NYSE American, LLC", dir.Name(models.AssetStocks, 1))
+	assert.Equal(t, "Coinbase", dir.Name(models.AssetCrypto, 1))
+	e, ok := dir.LastTrade(models.AssetCrypto, models.LastTradeSnapshot{ExchangeID: 1})
+	assert.True(t, ok)
+	assert.Equal(t, "global", e.Locale)
+
+	bid, ask := dir.Quote(models.AssetStocks, models.Quote{BidExchange: 1, AskExchange: 202})
+	assert.Equal(t, "XASE", bid.MIC)
+	assert.Equal(t, "FINN", ask.MIC)
+}
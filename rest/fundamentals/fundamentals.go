@@ -0,0 +1,406 @@
+// Package fundamentals computes trailing twelve month financials, ratios, and valuation multiples.
+//
+// A Series stitches the quarterly and annual filings returned by VXClient.ListStockFinancials into a sequence of fiscal
+// quarters and rolls them up into trailing twelve month (TTM) financials. Most companies don't file a quarterly report
+// for Q4, so missing Q4s are derived from the annual report minus Q1 through Q3:
+//
+//	s, err := fundamentals.Load(ctx, &c.VX, "AAPL")
+//	if err != nil {
+//		return err
+//	}
+//	for _, r := range s.Ratios() {
+//		log.Print(r.EndDate, r.NetMargin, r.ReturnOnEquity, r.EPSGrowth)
+//	}
+//	multiples := s.Multiples(ticker, aggs) // P/E, P/S, and P/B for every daily bar
+//
+// TTM financials have the shape of models.StockFinancial with a fiscal period of "TTM", so their typed statements can
+// be used as usual. Flows such as revenues and cash flows are summed over four consecutive quarters, while the balance
+// sheet is the one of the last quarter. Ratios that are undefined, e.g. because a line item isn't reported, are NaN.
+package fundamentals
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/iter"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+const (
+	periodFY  = "FY"
+	periodQ4  = "Q4"
+	periodTTM = "TTM"
+)
+
+var quarters = map[string]int{"Q1": 1, "Q2": 2, "Q3": 3, "Q4": 4}
+
+// Lister lists stock financials. The VXClient of the rest package implements it.
+type Lister interface {
+	ListStockFinancials(ctx context.Context, params *models.ListStockFinancialsParams, options ...models.RequestOption) *iter.Iter[models.StockFinancial]
+}
+
+// Series is the financial history of a company.
+type Series struct {
+	// Quarters are the fiscal quarters in order, including derived Q4s.
+	Quarters []models.StockFinancial
+
+	// TTM are the trailing twelve month financials in order, one for every quarter that ends four consecutive
+	// quarters. They must not be modified, since the series keeps their decoded statements.
+	TTM []models.StockFinancial
+
+	// ends are the indexes of the last quarters of the TTM periods
+	ends []int
+
+	// statements are the decoded statements of the TTM periods
+	statements []statements
+
+	// filed are the filing dates of the TTM periods in order, and latest are the indexes of the latest ending periods
+	// filed by each of them
+	filed  []civil.Date
+	latest []int
+}
+
+// statements are the typed statements of financials, which are decoded once since that takes a round trip through
+// JSON.
+type statements struct {
+	is models.IncomeStatement
+	bs models.BalanceSheet
+	cf models.CashFlowStatement
+}
+
+func decode(f models.StockFinancial) statements {
+	return statements{is: f.IncomeStatement(), bs: f.BalanceSheet(), cf: f.CashFlowStatement()}
+}
+
+// Ratios are the fundamental ratios of a TTM period.
+type Ratios struct {
+	FiscalYear int
+	EndDate    civil.Date
+	FilingDate civil.Date
+
+	// Margins are relative to revenues.
+	GrossMargin     float64
+	OperatingMargin float64
+	NetMargin       float64
+
+	// Returns are relative to the equity and assets at the end of the period.
+	ReturnOnEquity float64
+	ReturnOnAssets float64
+
+	// DebtToEquity is total liabilities over equity, since filings don't break out total debt.
+	DebtToEquity float64
+	CurrentRatio float64
+
+	// EPS is the diluted earnings per share, or the basic earnings per share if the diluted aren't reported.
+	EPS float64
+
+	// EPSGrowth is the change of EPS over the TTM period a year earlier, relative to its absolute value.
+	EPSGrowth float64
+}
+
+// Multiples are the valuation multiples of a price.
+type Multiples struct {
+	Timestamp models.Millis
+	Price     float64
+	MarketCap float64
+
+	PriceToEarnings float64
+	PriceToSales    float64
+	PriceToBook     float64
+	PriceToCashFlow float64
+	EarningsYield   float64
+
+	// EndDate and FilingDate are the ones of the TTM financials the multiples are based on, which are zero if none
+	// were filed yet.
+	EndDate    civil.Date
+	FilingDate civil.Date
+}
+
+// Load lists the quarterly and annual financials of a ticker and builds its series.
+func Load(ctx context.Context, client Lister, ticker string) (*Series, error) {
+	var financials []models.StockFinancial
+	for _, tf := range []models.Timeframe{models.TFQuarterly, models.TFAnnual} {
+		it := client.ListStockFinancials(ctx, models.ListStockFinancialsParams{}.WithTicker(ticker).WithTimeframe(tf).WithLimit(100))
+		for it.Next() {
+			financials = append(financials, it.Item())
+		}
+		if it.Err() != nil {
+			return nil, it.Err()
+		}
+	}
+	return New(financials), nil
+}
+
+// New builds a series from quarterly and annual financials in any order. Restated filings of the same period replace
+// earlier ones, and TTM filings are ignored in favor of computing them consistently.
+func New(financials []models.StockFinancial) *Series {
+	type period struct{ year, quarter int }
+	qs := make(map[period]models.StockFinancial)
+	fys := make(map[int]models.StockFinancial)
+	for _, f := range financials {
+		if q, ok := quarters[f.FiscalPeriod]; ok {
+			p := period{f.FiscalYear, q}
+			if prev, ok := qs[p]; !ok || !f.FilingDate.Before(prev.FilingDate) {
+				qs[p] = f
+			}
+		} else if f.FiscalPeriod == periodFY {
+			if prev, ok := fys[f.FiscalYear]; !ok || !f.FilingDate.Before(prev.FilingDate) {
+				fys[f.FiscalYear] = f
+			}
+		}
+	}
+
+	for year, fy := range fys {
+		if _, ok := qs[period{year, 4}]; ok {
+			continue
+		}
+		q1, ok1 := qs[period{year, 1}]
+		q2, ok2 := qs[period{year, 2}]
+		q3, ok3 := qs[period{year, 3}]
+		if ok1 && ok2 && ok3 {
+			qs[period{year, 4}] = deriveQ4(fy, []models.StockFinancial{q1, q2, q3})
+		}
+	}
+
+	s := &Series{}
+	for _, f := range qs {
+		s.Quarters = append(s.Quarters, f)
+	}
+	sort.Slice(s.Quarters, func(i, j int) bool { return index(s.Quarters[i]) < index(s.Quarters[j]) })
+
+	for i := 3; i < len(s.Quarters); i++ {
+		if index(s.Quarters[i])-index(s.Quarters[i-3]) == 3 {
+			s.TTM = append(s.TTM, ttm(s.Quarters[i-3:i+1]))
+			s.ends = append(s.ends, index(s.Quarters[i]))
+		}
+	}
+
+	byFiling := make([]int, len(s.TTM))
+	for i, f := range s.TTM {
+		byFiling[i] = i
+		s.statements = append(s.statements, decode(f))
+	}
+	sort.SliceStable(byFiling, func(i, j int) bool {
+		return s.TTM[byFiling[i]].FilingDate.Before(s.TTM[byFiling[j]].FilingDate)
+	})
+	for k, i := range byFiling {
+		latest := i
+		if k > 0 && s.TTM[i].EndDate.Before(s.TTM[s.latest[k-1]].EndDate) {
+			latest = s.latest[k-1]
+		}
+		s.filed = append(s.filed, s.TTM[i].FilingDate)
+		s.latest = append(s.latest, latest)
+	}
+	return s
+}
+
+// AsOf returns the latest TTM financials that were filed on or before a date, which avoids looking ahead when
+// backtesting.
+func (s *Series) AsOf(d civil.Date) (models.StockFinancial, bool) {
+	i, ok := s.asOf(d)
+	if !ok {
+		return models.StockFinancial{}, false
+	}
+	return s.TTM[i], true
+}
+
+func (s *Series) asOf(d civil.Date) (int, bool) {
+	k := sort.Search(len(s.filed), func(k int) bool { return s.filed[k].After(d) })
+	if k == 0 {
+		return 0, false
+	}
+	return s.latest[k-1], true
+}
+
+// Ratios returns the ratios of every TTM period in order.
+func (s *Series) Ratios() []Ratios {
+	out := make([]Ratios, 0, len(s.TTM))
+	for i, f := range s.TTM {
+		r := computeRatios(f, s.statements[i])
+		// the TTM period a year earlier is four quarters back if the quarters are consecutive
+		if i >= 4 && s.ends[i]-s.ends[i-4] == 4 {
+			prev := eps(s.statements[i-4].is)
+			r.EPSGrowth = ratio(r.EPS-prev, math.Abs(prev))
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Multiples returns the valuation multiples of every aggregate, using the TTM financials that were filed by the day
+// of the aggregate and the shares outstanding of a ticker. The shares outstanding are the current ones, so the market
+// cap and the multiples derived from it drift for companies that issued or bought back shares.
+func (s *Series) Multiples(ticker models.Ticker, aggs []models.Agg) []Multiples {
+	loc := tz.NewYork()
+	shares := float64(ticker.ShareClassSharesOutstanding)
+
+	out := make([]Multiples, 0, len(aggs))
+	for _, agg := range aggs {
+		m := Multiples{Timestamp: agg.Timestamp, Price: agg.Close, MarketCap: math.NaN()}
+		if shares > 0 {
+			m.MarketCap = shares * agg.Close
+		}
+		m.PriceToEarnings, m.PriceToSales, m.PriceToBook, m.PriceToCashFlow, m.EarningsYield = math.NaN(), math.NaN(), math.NaN(), math.NaN(), math.NaN()
+
+		if i, ok := s.asOf(civil.DateOf(time.Time(agg.Timestamp).In(loc))); ok {
+			f, st := s.TTM[i], s.statements[i]
+			is, bs, cf := st.is, st.bs, st.cf
+			e := eps(is)
+			m.EndDate, m.FilingDate = f.EndDate, f.FilingDate
+			m.PriceToEarnings = ratio(agg.Close, e)
+			m.EarningsYield = ratio(e, agg.Close)
+			m.PriceToSales = ratio(m.MarketCap, value(is.Revenues))
+			m.PriceToBook = ratio(m.MarketCap, equity(bs))
+			m.PriceToCashFlow = ratio(m.MarketCap, value(cf.NetCashFlowFromOperatingActivities))
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// ComputeRatios returns the ratios of TTM financials, without the EPS growth which needs the prior year.
+func ComputeRatios(f models.StockFinancial) Ratios {
+	return computeRatios(f, decode(f))
+}
+
+func computeRatios(f models.StockFinancial, st statements) Ratios {
+	is, bs := st.is, st.bs
+	net := value(is.NetIncomeLossAttributableToParent)
+	if math.IsNaN(net) {
+		net = value(is.NetIncomeLoss)
+	}
+
+	return Ratios{
+		FiscalYear:      f.FiscalYear,
+		EndDate:         f.EndDate,
+		FilingDate:      f.FilingDate,
+		GrossMargin:     ratio(value(is.GrossProfit), value(is.Revenues)),
+		OperatingMargin: ratio(value(is.OperatingIncomeLoss), value(is.Revenues)),
+		NetMargin:       ratio(net, value(is.Revenues)),
+		ReturnOnEquity:  ratio(net, equity(bs)),
+		ReturnOnAssets:  ratio(net, value(bs.Assets)),
+		DebtToEquity:    ratio(value(bs.Liabilities), equity(bs)),
+		CurrentRatio:    ratio(value(bs.CurrentAssets), value(bs.CurrentLiabilities)),
+		EPS:             eps(is),
+		EPSGrowth:       math.NaN(),
+	}
+}
+
+// deriveQ4 derives a Q4 from the annual financials and the first three quarters of a year.
+func deriveQ4(fy models.StockFinancial, qs []models.StockFinancial) models.StockFinancial {
+	q4 := fy
+	q4.FiscalPeriod = periodQ4
+	q4.StartDate = qs[2].EndDate.AddDays(1)
+	q4.Financials = make(map[string]models.Financial, len(fy.Financials))
+	for key, statement := range fy.Financials {
+		if key == models.BalanceSheetKey {
+			q4.Financials[key] = statement
+			continue
+		}
+		out := make(models.Financial, len(statement))
+		for item, dp := range statement {
+			// the average share count of a year is close to the one of its last quarter
+			if averaged(item) {
+				out[item] = dp
+				continue
+			}
+			if v, ok := sum(qs, key, item); ok {
+				dp.Value -= v
+				out[item] = dp
+			}
+		}
+		q4.Financials[key] = out
+	}
+	return q4
+}
+
+// ttm rolls four consecutive quarters up into TTM financials.
+func ttm(qs []models.StockFinancial) models.StockFinancial {
+	last := qs[len(qs)-1]
+	f := last
+	f.FiscalPeriod = periodTTM
+	f.StartDate = qs[0].StartDate
+	f.Financials = make(map[string]models.Financial, len(last.Financials))
+	for key, statement := range last.Financials {
+		if key == models.BalanceSheetKey {
+			f.Financials[key] = statement
+			continue
+		}
+		out := make(models.Financial, len(statement))
+		for item, dp := range statement {
+			v, ok := sum(qs, key, item)
+			if !ok {
+				continue
+			}
+			dp.Value = v
+			if averaged(item) {
+				dp.Value /= float64(len(qs))
+			}
+			out[item] = dp
+		}
+		f.Financials[key] = out
+	}
+	return f
+}
+
+// sum sums a line item over quarters. It's false if any quarter doesn't report the line item.
+func sum(qs []models.StockFinancial, key, item string) (float64, bool) {
+	var total float64
+	for _, q := range qs {
+		dp, ok := q.Financials[key][item]
+		if !ok {
+			return 0, false
+		}
+		total += dp.Value
+	}
+	return total, true
+}
+
+// averaged reports whether a line item is a share count, which is averaged rather than summed over quarters.
+func averaged(item string) bool {
+	return strings.HasSuffix(item, "_average_shares")
+}
+
+// index is the number of a fiscal quarter since year zero, so that consecutive quarters have consecutive indexes.
+func index(f models.StockFinancial) int {
+	q, ok := quarters[f.FiscalPeriod]
+	if !ok {
+		q = 4
+	}
+	return f.FiscalYear*4 + q - 1
+}
+
+func eps(is models.IncomeStatement) float64 {
+	if v := value(is.DilutedEarningsPerShare); !math.IsNaN(v) {
+		return v
+	}
+	return value(is.BasicEarningsPerShare)
+}
+
+func equity(bs models.BalanceSheet) float64 {
+	if v := value(bs.EquityAttributableToParent); !math.IsNaN(v) {
+		return v
+	}
+	return value(bs.Equity)
+}
+
+// value returns the value of a line item, or NaN if it isn't reported. Reported line items always have a unit.
+func value(dp models.DataPoint) float64 {
+	if dp.Unit == "" {
+		return math.NaN()
+	}
+	return dp.Value
+}
+
+// ratio divides two values, returning NaN instead of infinities.
+func ratio(a, b float64) float64 {
+	if b == 0 || math.IsNaN(a) || math.IsNaN(b) {
+		return math.NaN()
+	}
+	return a / b
+}
@@ -0,0 +1,131 @@
+package fundamentals_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/fundamentals"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func load(t *testing.T) *fundamentals.Series {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	series, err := fundamentals.Load(context.Background(), &s.Client("API_KEY").VX, "EXMP")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"/vX/reference/financials?limit=100&ticker=EXMP&timeframe=quarterly",
+		"/vX/reference/financials?limit=100&ticker=EXMP&timeframe=annual",
+	}, s.Requests())
+	return series
+}
+
+func TestSeries(t *testing.T) {
+	s := load(t)
+
+	// missing Q4s are derived from the annual report
+	assert.Len(t, s.Quarters, 8)
+	q4 := s.Quarters[3]
+	assert.Equal(t, "Q4", q4.FiscalPeriod)
+	assert.Equal(t, civil.Date{Year: 2022, Month: 10, Day: 1}, q4.StartDate)
+	assert.Equal(t, civil.Date{Year: 2023, Month: 2, Day: 2}, q4.FilingDate)
+	assert.InDelta(t, 130, q4.IncomeStatement().Revenues.Value, 1e-9)
+	assert.InDelta(t, 0.13, q4.IncomeStatement().DilutedEarningsPerShare.Value, 1e-9)
+	assert.Equal(t, 100.0, q4.IncomeStatement().DilutedAverageShares.Value)
+	assert.Equal(t, 1030.0, q4.BalanceSheet().Equity.Value)
+
+	// every quarter that ends four consecutive quarters has a TTM period
+	assert.Len(t, s.TTM, 5)
+	revenues := make([]float64, 0, len(s.TTM))
+	for _, f := range s.TTM {
+		assert.Equal(t, "TTM", f.FiscalPeriod)
+		revenues = append(revenues, math.Round(f.IncomeStatement().Revenues.Value))
+	}
+	assert.Equal(t, []float64{460, 490, 520, 550, 600}, revenues)
+	last := s.TTM[4]
+	assert.Equal(t, civil.Date{Year: 2023, Month: 1, Day: 1}, last.StartDate)
+	assert.Equal(t, 1100.0, last.BalanceSheet().Equity.Value)
+	assert.Equal(t, 100.0, last.IncomeStatement().DilutedAverageShares.Value)
+
+	r := s.Ratios()
+	assert.Len(t, r, 5)
+	assert.True(t, math.IsNaN(r[0].EPSGrowth))
+	assert.InDelta(t, 0.4, r[4].GrossMargin, 1e-9)
+	assert.InDelta(t, 0.1, r[4].NetMargin, 1e-9)
+	assert.InDelta(t, 60.0/1100, r[4].ReturnOnEquity, 1e-9)
+	assert.InDelta(t, 0.5, r[4].DebtToEquity, 1e-9)
+	assert.InDelta(t, 0.6, r[4].EPS, 1e-9)
+	assert.InDelta(t, (0.6-0.46)/0.46, r[4].EPSGrowth, 1e-9)
+
+	// line items that aren't reported make ratios undefined
+	assert.True(t, math.IsNaN(r[4].OperatingMargin))
+	assert.True(t, math.IsNaN(r[4].CurrentRatio))
+}
+
+func TestMultiples(t *testing.T) {
+	s := load(t)
+
+	ny, _ := time.LoadLocation("America/New_York")
+	agg := func(year int, month time.Month, day int, close float64) models.Agg {
+		return models.Agg{Timestamp: models.Millis(time.Date(year, month, day, 0, 0, 0, 0, ny)), Close: close}
+	}
+	m := s.Multiples(models.Ticker{ShareClassSharesOutstanding: 1000}, []models.Agg{
+		agg(2023, 1, 17, 20),
+		agg(2023, 2, 2, 23),
+		agg(2023, 5, 1, 24.5),
+	})
+	assert.Len(t, m, 3)
+
+	// financials are only used once they're filed
+	assert.Equal(t, 20000.0, m[0].MarketCap)
+	assert.True(t, math.IsNaN(m[0].PriceToEarnings))
+	assert.True(t, m[0].FilingDate.IsZero())
+
+	assert.InDelta(t, 23/0.46, m[1].PriceToEarnings, 1e-9)
+	assert.InDelta(t, 23000.0/460, m[1].PriceToSales, 1e-9)
+	assert.InDelta(t, 23000.0/1030, m[1].PriceToBook, 1e-9)
+	assert.InDelta(t, 23000.0/69, m[1].PriceToCashFlow, 1e-9)
+	assert.Equal(t, civil.Date{Year: 2022, Month: 12, Day: 31}, m[1].EndDate)
+
+	assert.InDelta(t, 24.5/0.49, m[2].PriceToEarnings, 1e-9)
+	assert.InDelta(t, 0.49/24.5, m[2].EarningsYield, 1e-9)
+
+	// without shares outstanding, only the per share multiples are defined
+	m = s.Multiples(models.Ticker{}, []models.Agg{agg(2023, 5, 1, 24.5)})
+	assert.True(t, math.IsNaN(m[0].PriceToSales))
+	assert.InDelta(t, 24.5/0.49, m[0].PriceToEarnings, 1e-9)
+}
+
+func TestAsOf(t *testing.T) {
+	quarter := func(year int, period string, end, filed string) models.StockFinancial {
+		e, _ := civil.ParseDate(end)
+		f, _ := civil.ParseDate(filed)
+		return models.StockFinancial{FiscalYear: year, FiscalPeriod: period, EndDate: e, FilingDate: f}
+	}
+	s := fundamentals.New([]models.StockFinancial{
+		quarter(2022, "Q1", "2022-03-31", "2022-05-01"),
+		quarter(2022, "Q2", "2022-06-30", "2022-08-01"),
+		quarter(2022, "Q3", "2022-09-30", "2022-11-01"),
+		quarter(2022, "Q4", "2022-12-31", "2023-02-01"),
+		quarter(2023, "Q1", "2023-03-31", "2023-05-01"),
+		// a restatement of Q4 filed after Q1
+		quarter(2022, "Q4", "2022-12-31", "2023-06-01"),
+	})
+	assert.Len(t, s.TTM, 2)
+
+	_, ok := s.AsOf(civil.Date{Year: 2023, Month: 3, Day: 1})
+	assert.False(t, ok)
+	f, ok := s.AsOf(civil.Date{Year: 2023, Month: 5, Day: 1})
+	assert.True(t, ok)
+	assert.Equal(t, civil.Date{Year: 2023, Month: 3, Day: 31}, f.EndDate)
+
+	// the restated period doesn't replace the later one
+	f, _ = s.AsOf(civil.Date{Year: 2023, Month: 7, Day: 1})
+	assert.Equal(t, civil.Date{Year: 2023, Month: 3, Day: 31}, f.EndDate)
+}
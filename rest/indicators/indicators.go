@@ -0,0 +1,195 @@
+// Package indicators computes technical indicators locally from aggregate bars.
+//
+// The SMA, EMA, MACD, and RSI results use the same value types returned by IndicatorsClient so that locally computed
+// series can be used wherever server-side values are expected. Every indicator is incremental: push one bar at a time
+// to get the newest value, which lets the same code run over historical bars from AggsClient.ListAggs and live
+// aggregates from the WebSocket client.
+//
+//	sma := indicators.NewSMA(20, models.Close)
+//	for agg := range aggs {
+//		if v, ok := sma.Push(indicators.FromEquityAgg(agg)); ok {
+//			log.Print(v.Value)
+//		}
+//	}
+//
+// Batch helpers such as SMA and MACD compute a full series from a slice of aggregates. Results are returned in the
+// order of the input bars, so pass bars in ascending order (the default for ListAggs).
+package indicators
+
+import (
+	"time"
+
+	"github.com/polygon-io/client-go/rest/models"
+	wsmodels "github.com/polygon-io/client-go/websocket/models"
+)
+
+// Bar is the set of aggregate fields used to compute indicators.
+type Bar struct {
+	Timestamp models.Millis
+	Open      float64
+	High      float64
+	Low       float64
+	Close     float64
+	Volume    float64
+	VWAP      float64
+}
+
+// FromAgg converts a REST aggregate to a bar.
+func FromAgg(a models.Agg) Bar {
+	return Bar{
+		Timestamp: a.Timestamp,
+		Open:      a.Open,
+		High:      a.High,
+		Low:       a.Low,
+		Close:     a.Close,
+		Volume:    a.Volume,
+		VWAP:      a.VWAP,
+	}
+}
+
+// FromEquityAgg converts a WebSocket stock or option aggregate to a bar. The bar is stamped with the start of the
+// aggregate window, which matches the REST aggregates.
+func FromEquityAgg(a wsmodels.EquityAgg) Bar {
+	return Bar{
+		Timestamp: models.Millis(time.UnixMilli(a.StartTimestamp)),
+		Open:      a.Open,
+		High:      a.High,
+		Low:       a.Low,
+		Close:     a.Close,
+		Volume:    a.Volume,
+		VWAP:      a.VWAP,
+	}
+}
+
+// FromCurrencyAgg converts a WebSocket forex or crypto aggregate to a bar.
+func FromCurrencyAgg(a wsmodels.CurrencyAgg) Bar {
+	return Bar{
+		Timestamp: models.Millis(time.UnixMilli(a.StartTimestamp)),
+		Open:      a.Open,
+		High:      a.High,
+		Low:       a.Low,
+		Close:     a.Close,
+		Volume:    a.Volume,
+		VWAP:      a.VWAP,
+	}
+}
+
+// Value returns the bar attribute selected by the series type. An empty series type selects the close.
+func (b Bar) Value(series models.SeriesType) float64 {
+	switch series {
+	case models.Open:
+		return b.Open
+	case models.High:
+		return b.High
+	case models.Low:
+		return b.Low
+	default:
+		return b.Close
+	}
+}
+
+// BandValue is a middle line with an upper and a lower band, e.g. Bollinger Bands.
+type BandValue struct {
+	Timestamp models.Millis `json:"timestamp,omitempty"`
+	Upper     float64       `json:"upper,omitempty"`
+	Middle    float64       `json:"middle,omitempty"`
+	Lower     float64       `json:"lower,omitempty"`
+}
+
+// StochasticValue is the %K and %D lines of a stochastic oscillator.
+type StochasticValue struct {
+	Timestamp models.Millis `json:"timestamp,omitempty"`
+	K         float64       `json:"k,omitempty"`
+	D         float64       `json:"d,omitempty"`
+}
+
+// SMA computes a simple moving average series from aggregates.
+func SMA(aggs []models.Agg, window int, series models.SeriesType) models.SingleIndicatorValues {
+	return collect(aggs, NewSMA(window, series).Push)
+}
+
+// EMA computes an exponential moving average series from aggregates.
+func EMA(aggs []models.Agg, window int, series models.SeriesType) models.SingleIndicatorValues {
+	return collect(aggs, NewEMA(window, series).Push)
+}
+
+// MACD computes a moving average convergence divergence series from aggregates.
+func MACD(aggs []models.Agg, shortWindow, longWindow, signalWindow int, series models.SeriesType) models.MACDIndicatorValues {
+	return collect(aggs, NewMACD(shortWindow, longWindow, signalWindow, series).Push)
+}
+
+// RSI computes a relative strength index series from aggregates.
+func RSI(aggs []models.Agg, window int, series models.SeriesType) models.SingleIndicatorValues {
+	return collect(aggs, NewRSI(window, series).Push)
+}
+
+// BollingerBands computes a Bollinger Bands series from aggregates.
+func BollingerBands(aggs []models.Agg, window int, k float64, series models.SeriesType) []BandValue {
+	return collect(aggs, NewBollingerBands(window, k, series).Push)
+}
+
+// ATR computes an average true range series from aggregates.
+func ATR(aggs []models.Agg, window int) models.SingleIndicatorValues {
+	return collect(aggs, NewATR(window).Push)
+}
+
+// VWAPBands computes a session VWAP with standard deviation bands from aggregates.
+func VWAPBands(aggs []models.Agg, k float64) []BandValue {
+	return collect(aggs, NewVWAPBands(k).Push)
+}
+
+// Stochastic computes a stochastic oscillator series from aggregates.
+func Stochastic(aggs []models.Agg, kWindow, dWindow int) []StochasticValue {
+	return collect(aggs, NewStochastic(kWindow, dWindow).Push)
+}
+
+// OBV computes an on-balance volume series from aggregates.
+func OBV(aggs []models.Agg) models.SingleIndicatorValues {
+	return collect(aggs, NewOBV().Push)
+}
+
+func collect[T any](aggs []models.Agg, push func(Bar) (T, bool)) []T {
+	var out []T
+	for _, a := range aggs {
+		if v, ok := push(FromAgg(a)); ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// window is a fixed size ring buffer of the most recent values.
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(size int) *window {
+	if size < 1 {
+		size = 1
+	}
+	return &window{values: make([]float64, size)}
+}
+
+// push adds a value and returns the value it evicted, if any.
+func (w *window) push(v float64) (float64, bool) {
+	old, evicted := w.values[w.next], w.full
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+	return old, evicted
+}
+
+func (w *window) each(f func(float64)) {
+	n := len(w.values)
+	if !w.full {
+		n = w.next
+	}
+	for i := 0; i < n; i++ {
+		f(w.values[i])
+	}
+}
@@ -0,0 +1,280 @@
+package indicators_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/indicators"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	wsmodels "github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func closes(cs ...float64) []models.Agg {
+	days := []int{2, 3, 4, 5, 8, 9, 10, 11, 12}
+	aggs := make([]models.Agg, len(cs))
+	for i, c := range cs {
+		aggs[i] = bar(days[i], 0, 0, 0, c, 0)
+	}
+	return aggs
+}
+
+func values(vs models.SingleIndicatorValues) []float64 {
+	out := make([]float64, len(vs))
+	for i, v := range vs {
+		out[i] = v.Value
+	}
+	return out
+}
+
+func TestSMA(t *testing.T) {
+	assert.InDeltaSlice(t, []float64{4, 6, 6}, values(indicators.SMA(closes(2, 4, 6, 8, 4), 3, models.Close)), 1e-9)
+}
+
+func TestEMA(t *testing.T) {
+	// a window of 3 smooths by 0.5, seeded with the simple average of the first 3 closes
+	ema := indicators.EMA(closes(2, 4, 6, 8, 4), 3, models.Close)
+	assert.InDeltaSlice(t, []float64{4, 6, 5}, values(ema), 1e-9)
+	assert.Equal(t, time.Date(2024, 1, 4, 15, 0, 0, 0, time.UTC), time.Time(ema[0].Timestamp))
+}
+
+func TestRSI(t *testing.T) {
+	// changes are +2, -1, +2, -1, so the averages start at 1 and 0.5 and are then smoothed by half
+	rsi := indicators.RSI(closes(10, 12, 11, 13, 12), 2, models.Close)
+	assert.InDeltaSlice(t, []float64{100 - 100/3.0, 100 - 100/7.0, 100 - 100/2.2}, values(rsi), 1e-9)
+}
+
+func TestMACD(t *testing.T) {
+	// the short EMA is 1.5, 19/6, 103/18, 499/54 and the long EMA is 7/3, 14/3, 47/6, so the MACD is 5/6, 19/18, 38/27
+	macd := indicators.MACD(closes(1, 2, 4, 7, 11), 2, 3, 2, models.Close)
+	assert.Len(t, macd, 2)
+	assert.InDelta(t, 19/18.0, macd[0].Value, 1e-9)
+	assert.InDelta(t, 17/18.0, macd[0].Signal, 1e-9)
+	assert.InDelta(t, 1/9.0, macd[0].Histogram, 1e-9)
+	assert.InDelta(t, 38/27.0, macd[1].Value, 1e-9)
+	assert.InDelta(t, (2*38/27.0+17/18.0)/3, macd[1].Signal, 1e-9)
+}
+
+func TestIncrementalEquityAgg(t *testing.T) {
+	aggs := closes(2, 4, 6, 8, 4, 3, 9)
+	batch := indicators.SMA(aggs, 3, models.Close)
+
+	sma := indicators.NewSMA(3, models.Close)
+	var live models.SingleIndicatorValues
+	for _, a := range aggs {
+		ws := wsmodels.EquityAgg{
+			Symbol:         "AAPL",
+			Close:          a.Close,
+			StartTimestamp: time.Time(a.Timestamp).UnixMilli(),
+		}
+		if v, ok := sma.Push(indicators.FromEquityAgg(ws)); ok {
+			live = append(live, v)
+		}
+	}
+	assert.Equal(t, values(batch), values(live))
+	for i := range live {
+		assert.True(t, time.Time(batch[i].Timestamp).Equal(time.Time(live[i].Timestamp)))
+	}
+}
+
+// The server tests compare local values with IndicatorsClient responses served from testdata with
+// expand_underlying=true. A missing fixture fails the test; fixtures can be recorded from the real API with:
+//
+//	RECORD=1 POLYGON_API_KEY=... go test ./rest/indicators
+//
+// The server seeds exponential averages and Wilder's smoothing from bars before the underlying aggregates it returns,
+// while the local indicators seed from the first bars they see. The seeds decay geometrically, so only values after
+// warmup bars are compared.
+
+const (
+	serverTicker = "AAPL"
+	serverLimit  = 300
+)
+
+var (
+	serverFrom = models.Millis(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+	serverTo   = models.Millis(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC))
+)
+
+func serverClient(t *testing.T) *polygon.Client {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata", Record: os.Getenv("RECORD") != ""})
+	t.Cleanup(s.Close)
+	return s.Client(os.Getenv("POLYGON_API_KEY"))
+}
+
+// checkServer compares the server values with the local values of the same timestamps, skipping the first warmup local
+// values.
+func checkServer[T any](t *testing.T, expect, actual []T, ts func(T) models.Millis, check func(e, a T), warmup int) {
+	local := make(map[int64]T)
+	for i, a := range actual {
+		if i >= warmup {
+			local[time.Time(ts(a)).UnixMilli()] = a
+		}
+	}
+	var compared int
+	for _, e := range expect {
+		if a, ok := local[time.Time(ts(e)).UnixMilli()]; ok {
+			check(e, a)
+			compared++
+		}
+	}
+	assert.Greater(t, compared, 0)
+}
+
+// ascending reverses the underlying aggregates of a response, which are in descending order like its values.
+func ascending(aggs []models.Agg) []models.Agg {
+	out := make([]models.Agg, len(aggs))
+	for i, a := range aggs {
+		out[len(aggs)-1-i] = a
+	}
+	return out
+}
+
+func singleTimestamp(v models.SingleIndicatorValue) models.Millis { return v.Timestamp }
+
+func checkSingle(t *testing.T) func(e, a models.SingleIndicatorValue) {
+	return func(e, a models.SingleIndicatorValue) {
+		assert.InDelta(t, e.Value, a.Value, 1e-4, time.Time(e.Timestamp).String())
+	}
+}
+
+func TestServerSMA(t *testing.T) {
+	res, err := serverClient(t).GetSMA(context.Background(), models.GetSMAParams{Ticker: serverTicker}.
+		WithTimespan(models.Day).WithTimestamp(models.GTE, serverFrom).WithTimestamp(models.LTE, serverTo).
+		WithSeriesType(models.Close).WithExpandUnderlying(true).WithOrder(models.Desc).WithLimit(serverLimit).
+		WithWindow(20))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	actual := indicators.SMA(ascending(res.Results.Underlying.Aggregates), 20, models.Close)
+	checkServer(t, res.Results.Values, actual, singleTimestamp, checkSingle(t), 0)
+}
+
+func TestServerEMA(t *testing.T) {
+	res, err := serverClient(t).GetEMA(context.Background(), models.GetEMAParams{Ticker: serverTicker}.
+		WithTimespan(models.Day).WithTimestamp(models.GTE, serverFrom).WithTimestamp(models.LTE, serverTo).
+		WithSeriesType(models.Close).WithExpandUnderlying(true).WithOrder(models.Desc).WithLimit(serverLimit).
+		WithWindow(10))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	actual := indicators.EMA(ascending(res.Results.Underlying.Aggregates), 10, models.Close)
+	checkServer(t, res.Results.Values, actual, singleTimestamp, checkSingle(t), 60)
+}
+
+func TestServerRSI(t *testing.T) {
+	res, err := serverClient(t).GetRSI(context.Background(), models.GetRSIParams{Ticker: serverTicker}.
+		WithTimespan(models.Day).WithTimestamp(models.GTE, serverFrom).WithTimestamp(models.LTE, serverTo).
+		WithSeriesType(models.Close).WithExpandUnderlying(true).WithOrder(models.Desc).WithLimit(serverLimit).
+		WithWindow(14))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	actual := indicators.RSI(ascending(res.Results.Underlying.Aggregates), 14, models.Close)
+	checkServer(t, res.Results.Values, actual, singleTimestamp, checkSingle(t), 160)
+}
+
+func TestServerMACD(t *testing.T) {
+	res, err := serverClient(t).GetMACD(context.Background(), models.GetMACDParams{Ticker: serverTicker}.
+		WithTimespan(models.Day).WithTimestamp(models.GTE, serverFrom).WithTimestamp(models.LTE, serverTo).
+		WithSeriesType(models.Close).WithExpandUnderlying(true).WithOrder(models.Desc).WithLimit(serverLimit).
+		WithShortWindow(12).WithLongWindow(26).WithSignalWindow(9))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	actual := indicators.MACD(ascending(res.Results.Underlying.Aggregates), 12, 26, 9, models.Close)
+	checkServer(t, res.Results.Values, actual,
+		func(v models.MACDIndicatorValue) models.Millis { return v.Timestamp },
+		func(e, a models.MACDIndicatorValue) {
+			assert.InDelta(t, e.Value, a.Value, 1e-4, time.Time(e.Timestamp).String())
+			assert.InDelta(t, e.Signal, a.Signal, 1e-4, time.Time(e.Timestamp).String())
+			assert.InDelta(t, e.Histogram, a.Histogram, 1e-4, time.Time(e.Timestamp).String())
+		}, 160)
+}
+
+func bar(day int, o, h, l, c, v float64) models.Agg {
+	ts := time.Date(2024, 1, day, 15, 0, 0, 0, time.UTC)
+	return models.Agg{Open: o, High: h, Low: l, Close: c, Volume: v, Timestamp: models.Millis(ts)}
+}
+
+func TestBollingerBands(t *testing.T) {
+	aggs := []models.Agg{
+		bar(2, 0, 0, 0, 2, 0),
+		bar(3, 0, 0, 0, 4, 0),
+		bar(4, 0, 0, 0, 4, 0),
+		bar(5, 0, 0, 0, 4, 0),
+		bar(8, 0, 0, 0, 5, 0),
+		bar(9, 0, 0, 0, 5, 0),
+		bar(10, 0, 0, 0, 7, 0),
+		bar(11, 0, 0, 0, 9, 0),
+	}
+	bands := indicators.BollingerBands(aggs, 8, 2, models.Close)
+	assert.Len(t, bands, 1)
+	assert.InDelta(t, 5, bands[0].Middle, 1e-9)
+	assert.InDelta(t, 9, bands[0].Upper, 1e-9)
+	assert.InDelta(t, 1, bands[0].Lower, 1e-9)
+}
+
+func TestATR(t *testing.T) {
+	aggs := []models.Agg{
+		bar(2, 10, 12, 9, 11, 0),
+		bar(3, 11, 13, 10, 12, 0),
+		bar(4, 12, 12.5, 8, 9, 0),
+		bar(5, 9, 11, 8.5, 10, 0),
+	}
+	atr := indicators.ATR(aggs, 3)
+	assert.Len(t, atr, 2)
+	// true ranges are 3, 3, 4.5, 2.5
+	assert.InDelta(t, 3.5, atr[0].Value, 1e-9)
+	assert.InDelta(t, (3.5*2+2.5)/3, atr[1].Value, 1e-9)
+}
+
+func TestStochastic(t *testing.T) {
+	aggs := []models.Agg{
+		bar(2, 0, 10, 8, 9, 0),
+		bar(3, 0, 11, 9, 11, 0),
+		bar(4, 0, 12, 10, 10, 0),
+		bar(5, 0, 12, 9, 12, 0),
+	}
+	st := indicators.Stochastic(aggs, 3, 2)
+	assert.Len(t, st, 1)
+	assert.InDelta(t, 100, st[0].K, 1e-9)
+	assert.InDelta(t, (50+100)/2.0, st[0].D, 1e-9)
+}
+
+func TestOBV(t *testing.T) {
+	aggs := []models.Agg{
+		bar(2, 0, 0, 0, 10, 100),
+		bar(3, 0, 0, 0, 11, 200),
+		bar(4, 0, 0, 0, 10.5, 50),
+		bar(5, 0, 0, 0, 10.5, 75),
+	}
+	obv := indicators.OBV(aggs)
+	assert.Len(t, obv, 4)
+	assert.Equal(t, []float64{0, 200, 150, 150}, []float64{obv[0].Value, obv[1].Value, obv[2].Value, obv[3].Value})
+}
+
+func TestVWAPBands(t *testing.T) {
+	aggs := []models.Agg{
+		{VWAP: 10, Volume: 100, Timestamp: models.Millis(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))},
+		{VWAP: 12, Volume: 100, Timestamp: models.Millis(time.Date(2024, 1, 2, 16, 0, 0, 0, time.UTC))},
+		{VWAP: 20, Volume: 50, Timestamp: models.Millis(time.Date(2024, 1, 3, 15, 0, 0, 0, time.UTC))},
+	}
+	bands := indicators.VWAPBands(aggs, 1)
+	assert.Len(t, bands, 3)
+	assert.InDelta(t, 11, bands[1].Middle, 1e-9)
+	assert.InDelta(t, 12, bands[1].Upper, 1e-9)
+	assert.InDelta(t, 10, bands[1].Lower, 1e-9)
+
+	// the next trading day starts a new session
+	assert.InDelta(t, 20, bands[2].Middle, 1e-9)
+	assert.InDelta(t, 20, bands[2].Upper, 1e-9)
+}
@@ -0,0 +1,121 @@
+package indicators
+
+import "github.com/polygon-io/client-go/rest/models"
+
+// SMAIndicator is an incremental simple moving average.
+type SMAIndicator struct {
+	series models.SeriesType
+	sma    *sma
+}
+
+// NewSMA returns a simple moving average over the given number of bars.
+func NewSMA(window int, series models.SeriesType) *SMAIndicator {
+	return &SMAIndicator{series: series, sma: newSMA(window)}
+}
+
+// Push adds a bar and returns the newest average once the window is full.
+func (i *SMAIndicator) Push(b Bar) (models.SingleIndicatorValue, bool) {
+	v, ok := i.sma.update(b.Value(i.series))
+	return models.SingleIndicatorValue{Timestamp: b.Timestamp, Value: v}, ok
+}
+
+// EMAIndicator is an incremental exponential moving average.
+type EMAIndicator struct {
+	series models.SeriesType
+	ema    *ema
+}
+
+// NewEMA returns an exponential moving average with a smoothing factor of 2/(window+1). The average is seeded with the
+// simple average of the first window bars.
+func NewEMA(window int, series models.SeriesType) *EMAIndicator {
+	return &EMAIndicator{series: series, ema: newEMA(window)}
+}
+
+// Push adds a bar and returns the newest average once the average has been seeded.
+func (i *EMAIndicator) Push(b Bar) (models.SingleIndicatorValue, bool) {
+	v, ok := i.ema.update(b.Value(i.series))
+	return models.SingleIndicatorValue{Timestamp: b.Timestamp, Value: v}, ok
+}
+
+// MACDIndicator is an incremental moving average convergence divergence.
+type MACDIndicator struct {
+	series models.SeriesType
+	short  *ema
+	long   *ema
+	signal *ema
+}
+
+// NewMACD returns a MACD whose value is the difference between a short and a long EMA, whose signal line is an EMA of
+// that difference, and whose histogram is the difference between the value and the signal line.
+func NewMACD(shortWindow, longWindow, signalWindow int, series models.SeriesType) *MACDIndicator {
+	return &MACDIndicator{
+		series: series,
+		short:  newEMA(shortWindow),
+		long:   newEMA(longWindow),
+		signal: newEMA(signalWindow),
+	}
+}
+
+// Push adds a bar and returns the newest value once the signal line has been seeded.
+func (i *MACDIndicator) Push(b Bar) (models.MACDIndicatorValue, bool) {
+	x := b.Value(i.series)
+	s, sok := i.short.update(x)
+	l, lok := i.long.update(x)
+	if !sok || !lok {
+		return models.MACDIndicatorValue{Timestamp: b.Timestamp}, false
+	}
+
+	macd := s - l
+	signal, ok := i.signal.update(macd)
+	return models.MACDIndicatorValue{
+		Timestamp: b.Timestamp,
+		Value:     macd,
+		Signal:    signal,
+		Histogram: macd - signal,
+	}, ok
+}
+
+type sma struct {
+	w *window
+}
+
+func newSMA(size int) *sma {
+	return &sma{w: newWindow(size)}
+}
+
+func (s *sma) update(v float64) (float64, bool) {
+	s.w.push(v)
+	if !s.w.full {
+		return 0, false
+	}
+	return s.mean(), true
+}
+
+func (s *sma) mean() float64 {
+	var sum float64
+	s.w.each(func(v float64) { sum += v })
+	return sum / float64(len(s.w.values))
+}
+
+type ema struct {
+	alpha float64
+	seed  *sma
+	value float64
+	ready bool
+}
+
+func newEMA(size int) *ema {
+	if size < 1 {
+		size = 1
+	}
+	return &ema{alpha: 2 / float64(size+1), seed: newSMA(size)}
+}
+
+func (e *ema) update(v float64) (float64, bool) {
+	if e.ready {
+		e.value = e.alpha*v + (1-e.alpha)*e.value
+		return e.value, true
+	}
+	e.value, e.ready = e.seed.update(v)
+	return e.value, e.ready
+}
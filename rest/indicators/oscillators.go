@@ -0,0 +1,106 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// RSIIndicator is an incremental relative strength index using Wilder's smoothing.
+type RSIIndicator struct {
+	series models.SeriesType
+	size   int
+	prev   float64
+	count  int
+	gain   float64
+	loss   float64
+}
+
+// NewRSI returns a relative strength index over the given number of bars. The first value is available after
+// window+1 bars since each bar contributes the change from the previous one.
+func NewRSI(window int, series models.SeriesType) *RSIIndicator {
+	if window < 1 {
+		window = 1
+	}
+	return &RSIIndicator{series: series, size: window}
+}
+
+// Push adds a bar and returns the newest index value once enough changes have been seen.
+func (i *RSIIndicator) Push(b Bar) (models.SingleIndicatorValue, bool) {
+	x := b.Value(i.series)
+	res := models.SingleIndicatorValue{Timestamp: b.Timestamp}
+
+	i.count++
+	if i.count == 1 {
+		i.prev = x
+		return res, false
+	}
+
+	change := x - i.prev
+	i.prev = x
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	n := float64(i.size)
+	if i.count <= i.size+1 {
+		// accumulate a simple average of the first window changes
+		i.gain += gain / n
+		i.loss += loss / n
+		if i.count < i.size+1 {
+			return res, false
+		}
+	} else {
+		i.gain = (i.gain*(n-1) + gain) / n
+		i.loss = (i.loss*(n-1) + loss) / n
+	}
+
+	switch {
+	case i.loss == 0 && i.gain == 0:
+		res.Value = 50
+	case i.loss == 0:
+		res.Value = 100
+	default:
+		res.Value = 100 - 100/(1+i.gain/i.loss)
+	}
+	return res, true
+}
+
+// StochasticIndicator is an incremental stochastic oscillator.
+type StochasticIndicator struct {
+	highs *window
+	lows  *window
+	d     *sma
+}
+
+// NewStochastic returns a stochastic oscillator where %K compares the close to the high-low range of the last kWindow
+// bars and %D is the simple average of the last dWindow %K values.
+func NewStochastic(kWindow, dWindow int) *StochasticIndicator {
+	return &StochasticIndicator{
+		highs: newWindow(kWindow),
+		lows:  newWindow(kWindow),
+		d:     newSMA(dWindow),
+	}
+}
+
+// Push adds a bar and returns the newest value once both lines are available.
+func (i *StochasticIndicator) Push(b Bar) (StochasticValue, bool) {
+	res := StochasticValue{Timestamp: b.Timestamp}
+
+	i.highs.push(b.High)
+	i.lows.push(b.Low)
+	if !i.highs.full {
+		return res, false
+	}
+
+	hh, ll := math.Inf(-1), math.Inf(1)
+	i.highs.each(func(v float64) { hh = math.Max(hh, v) })
+	i.lows.each(func(v float64) { ll = math.Min(ll, v) })
+
+	res.K = 50
+	if hh > ll {
+		res.K = 100 * (b.Close - ll) / (hh - ll)
+	}
+
+	var ok bool
+	res.D, ok = i.d.update(res.K)
+	return res, ok
+}
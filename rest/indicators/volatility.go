@@ -0,0 +1,73 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// BollingerBandsIndicator is an incremental set of Bollinger Bands.
+type BollingerBandsIndicator struct {
+	series models.SeriesType
+	k      float64
+	sma    *sma
+}
+
+// NewBollingerBands returns bands placed k population standard deviations above and below a simple moving average.
+func NewBollingerBands(window int, k float64, series models.SeriesType) *BollingerBandsIndicator {
+	return &BollingerBandsIndicator{series: series, k: k, sma: newSMA(window)}
+}
+
+// Push adds a bar and returns the newest bands once the window is full.
+func (i *BollingerBandsIndicator) Push(b Bar) (BandValue, bool) {
+	mean, ok := i.sma.update(b.Value(i.series))
+	if !ok {
+		return BandValue{Timestamp: b.Timestamp}, false
+	}
+
+	var ss float64
+	i.sma.w.each(func(v float64) { ss += (v - mean) * (v - mean) })
+	dev := i.k * math.Sqrt(ss/float64(len(i.sma.w.values)))
+
+	return BandValue{
+		Timestamp: b.Timestamp,
+		Upper:     mean + dev,
+		Middle:    mean,
+		Lower:     mean - dev,
+	}, true
+}
+
+// ATRIndicator is an incremental average true range using Wilder's smoothing.
+type ATRIndicator struct {
+	size      int
+	prevClose float64
+	count     int
+	value     float64
+}
+
+// NewATR returns an average true range over the given number of bars.
+func NewATR(window int) *ATRIndicator {
+	if window < 1 {
+		window = 1
+	}
+	return &ATRIndicator{size: window}
+}
+
+// Push adds a bar and returns the newest average once the window is full.
+func (i *ATRIndicator) Push(b Bar) (models.SingleIndicatorValue, bool) {
+	tr := b.High - b.Low
+	if i.count > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(b.High-i.prevClose), math.Abs(b.Low-i.prevClose)))
+	}
+	i.prevClose = b.Close
+	i.count++
+
+	n := float64(i.size)
+	if i.count <= i.size {
+		i.value += tr / n
+	} else {
+		i.value = (i.value*(n-1) + tr) / n
+	}
+
+	return models.SingleIndicatorValue{Timestamp: b.Timestamp, Value: i.value}, i.count >= i.size
+}
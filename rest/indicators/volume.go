@@ -0,0 +1,84 @@
+package indicators
+
+import (
+	"math"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// VWAPBandsIndicator is an incremental session VWAP with volume weighted standard deviation bands.
+type VWAPBandsIndicator struct {
+	// Location is the time zone used to detect session boundaries. It defaults to America/New_York.
+	Location *time.Location
+
+	k       float64
+	session civil.Date
+	volume  float64
+	pv      float64
+	ppv     float64
+}
+
+// NewVWAPBands returns a VWAP that resets at the start of each trading day, with bands placed k standard deviations
+// above and below it. Each bar contributes its VWAP, or its typical price (high+low+close)/3 if the VWAP is missing,
+// weighted by its volume.
+func NewVWAPBands(k float64) *VWAPBandsIndicator {
+	return &VWAPBandsIndicator{Location: tz.NewYork(), k: k}
+}
+
+// Push adds a bar and returns the newest bands once the session has traded volume.
+func (i *VWAPBandsIndicator) Push(b Bar) (BandValue, bool) {
+	res := BandValue{Timestamp: b.Timestamp}
+
+	loc := i.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if d := civil.DateOf(time.Time(b.Timestamp).In(loc)); d != i.session {
+		i.session, i.volume, i.pv, i.ppv = d, 0, 0, 0
+	}
+
+	p := b.VWAP
+	if p == 0 {
+		p = (b.High + b.Low + b.Close) / 3
+	}
+	i.volume += b.Volume
+	i.pv += p * b.Volume
+	i.ppv += p * p * b.Volume
+	if i.volume <= 0 {
+		return res, false
+	}
+
+	vwap := i.pv / i.volume
+	dev := i.k * math.Sqrt(math.Max(i.ppv/i.volume-vwap*vwap, 0))
+	res.Upper, res.Middle, res.Lower = vwap+dev, vwap, vwap-dev
+	return res, true
+}
+
+// OBVIndicator is an incremental on-balance volume.
+type OBVIndicator struct {
+	prevClose float64
+	started   bool
+	value     float64
+}
+
+// NewOBV returns an on-balance volume that starts at zero on the first bar.
+func NewOBV() *OBVIndicator {
+	return &OBVIndicator{}
+}
+
+// Push adds a bar and returns the newest cumulative volume.
+func (i *OBVIndicator) Push(b Bar) (models.SingleIndicatorValue, bool) {
+	if i.started {
+		switch {
+		case b.Close > i.prevClose:
+			i.value += b.Volume
+		case b.Close < i.prevClose:
+			i.value -= b.Volume
+		}
+	}
+	i.prevClose, i.started = b.Close, true
+	return models.SingleIndicatorValue{Timestamp: b.Timestamp, Value: i.value}, true
+}
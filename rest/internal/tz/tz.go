@@ -0,0 +1,19 @@
+// Package tz provides the time zone of the US exchanges.
+package tz
+
+import (
+	"sync"
+	"time"
+
+	// the exchange time zone must not depend on the zoneinfo of the host
+	_ "time/tzdata"
+)
+
+// NewYork returns the America/New_York location, which is loaded once and shared.
+var NewYork = sync.OnceValue(func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		panic("missing exchange time zone: " + err.Error())
+	}
+	return loc
+})
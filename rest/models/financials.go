@@ -1,6 +1,12 @@
 package models
 
-import "cloud.google.com/go/civil"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"cloud.google.com/go/civil"
+)
 
 // ListStockFinancialsParams is the set of parameters for the ListStockFinancials method.
 type ListStockFinancialsParams struct {
@@ -153,18 +159,123 @@ type ListStockFinancialsResponse struct {
 type StockFinancial struct {
 	CIK                 string               `json:"cik,omitempty"`
 	CompanyName         string               `json:"company_name,omitempty"`
-	EndDate             string               `json:"end_date,omitempty"`
-	FilingDate          string               `json:"filing_date,omitempty"`
+	EndDate             civil.Date           `json:"end_date,omitempty"`
+	FilingDate          civil.Date           `json:"filing_date,omitempty"`
 	Financials          map[string]Financial `json:"financials,omitempty"`
 	FiscalPeriod        string               `json:"fiscal_period,omitempty"`
-	FiscalYear          string               `json:"fiscal_year,omitempty"`
+	FiscalYear          int                  `json:"fiscal_year,omitempty"`
 	SourceFilingFileUrl string               `json:"source_filing_file_url,omitempty"`
 	SourceFilingUrl     string               `json:"source_filing_url,omitempty"`
-	StartDate           string               `json:"start_date,omitempty"`
+	StartDate           civil.Date           `json:"start_date,omitempty"`
+}
+
+// UnmarshalJSON decodes the dates and fiscal year of a financial, which the API returns as strings that can be empty.
+func (f *StockFinancial) UnmarshalJSON(data []byte) error {
+	type alias StockFinancial
+	aux := struct {
+		*alias
+		EndDate    string `json:"end_date,omitempty"`
+		FilingDate string `json:"filing_date,omitempty"`
+		FiscalYear string `json:"fiscal_year,omitempty"`
+		StartDate  string `json:"start_date,omitempty"`
+	}{alias: (*alias)(f)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if f.EndDate, err = parseDate(aux.EndDate); err != nil {
+		return err
+	}
+	if f.FilingDate, err = parseDate(aux.FilingDate); err != nil {
+		return err
+	}
+	if f.StartDate, err = parseDate(aux.StartDate); err != nil {
+		return err
+	}
+	f.FiscalYear = 0
+	if aux.FiscalYear != "" {
+		if f.FiscalYear, err = strconv.Atoi(aux.FiscalYear); err != nil {
+			return fmt.Errorf("invalid fiscal year: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes a financial in the format of the API.
+func (f StockFinancial) MarshalJSON() ([]byte, error) {
+	type alias StockFinancial
+	aux := struct {
+		alias
+		EndDate    string `json:"end_date,omitempty"`
+		FilingDate string `json:"filing_date,omitempty"`
+		FiscalYear string `json:"fiscal_year,omitempty"`
+		StartDate  string `json:"start_date,omitempty"`
+	}{alias: alias(f)}
+	aux.EndDate = formatDate(f.EndDate)
+	aux.FilingDate = formatDate(f.FilingDate)
+	aux.StartDate = formatDate(f.StartDate)
+	if f.FiscalYear != 0 {
+		aux.FiscalYear = strconv.Itoa(f.FiscalYear)
+	}
+	return json.Marshal(aux)
+}
+
+func parseDate(s string) (civil.Date, error) {
+	if s == "" {
+		return civil.Date{}, nil
+	}
+	return civil.ParseDate(s)
+}
+
+func formatDate(d civil.Date) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.String()
+}
+
+// The keys of the statements in StockFinancial.Financials.
+const (
+	IncomeStatementKey     = "income_statement"
+	BalanceSheetKey        = "balance_sheet"
+	CashFlowStatementKey   = "cash_flow_statement"
+	ComprehensiveIncomeKey = "comprehensive_income"
+)
+
+// IncomeStatement returns the typed income statement of the financial.
+func (f StockFinancial) IncomeStatement() IncomeStatement {
+	var s IncomeStatement
+	f.Financials[IncomeStatementKey].decode(&s)
+	return s
+}
+
+// BalanceSheet returns the typed balance sheet of the financial.
+func (f StockFinancial) BalanceSheet() BalanceSheet {
+	var s BalanceSheet
+	f.Financials[BalanceSheetKey].decode(&s)
+	return s
+}
+
+// CashFlowStatement returns the typed cash flow statement of the financial.
+func (f StockFinancial) CashFlowStatement() CashFlowStatement {
+	var s CashFlowStatement
+	f.Financials[CashFlowStatementKey].decode(&s)
+	return s
+}
+
+// ComprehensiveIncome returns the typed comprehensive income statement of the financial.
+func (f StockFinancial) ComprehensiveIncome() ComprehensiveIncome {
+	var s ComprehensiveIncome
+	f.Financials[ComprehensiveIncomeKey].decode(&s)
+	return s
 }
 
 // Financial aliases nested data points of information for a stock financial.
-type Financial map[string]struct {
+type Financial map[string]DataPoint
+
+// DataPoint is a line item of a financial statement.
+type DataPoint struct {
 	Formula string  `json:"formula,omitempty"`
 	Label   string  `json:"label,omitempty"`
 	Order   int32   `json:"order,omitempty"`
@@ -172,3 +283,93 @@ type Financial map[string]struct {
 	Value   float64 `json:"value,omitempty"`
 	Xpath   string  `json:"xpath,omitempty"`
 }
+
+// decode copies the data points of the financial into the fields of a statement by their JSON keys.
+func (f Financial) decode(statement any) {
+	if len(f) == 0 {
+		return
+	}
+	// a financial always marshals into an object that fits any statement, so this can't fail
+	data, _ := json.Marshal(f)
+	_ = json.Unmarshal(data, statement)
+}
+
+// IncomeStatement holds the known line items of an income statement. Line items that a filing doesn't report are
+// zero, and line items that aren't listed here are still in StockFinancial.Financials.
+type IncomeStatement struct {
+	Revenues                                                            DataPoint `json:"revenues,omitempty"`
+	CostOfRevenue                                                       DataPoint `json:"cost_of_revenue,omitempty"`
+	GrossProfit                                                         DataPoint `json:"gross_profit,omitempty"`
+	OperatingExpenses                                                   DataPoint `json:"operating_expenses,omitempty"`
+	SellingGeneralAndAdministrativeExpenses                             DataPoint `json:"selling_general_and_administrative_expenses,omitempty"`
+	ResearchAndDevelopment                                              DataPoint `json:"research_and_development,omitempty"`
+	DepreciationAndAmortization                                         DataPoint `json:"depreciation_and_amortization,omitempty"`
+	CostsAndExpenses                                                    DataPoint `json:"costs_and_expenses,omitempty"`
+	BenefitsCostsExpenses                                               DataPoint `json:"benefits_costs_expenses,omitempty"`
+	OperatingIncomeLoss                                                 DataPoint `json:"operating_income_loss,omitempty"`
+	InterestExpenseOperating                                            DataPoint `json:"interest_expense_operating,omitempty"`
+	NonoperatingIncomeLoss                                              DataPoint `json:"nonoperating_income_loss,omitempty"`
+	IncomeLossFromEquityMethodInvestments                               DataPoint `json:"income_loss_from_equity_method_investments,omitempty"`
+	IncomeLossBeforeEquityMethodInvestments                             DataPoint `json:"income_loss_before_equity_method_investments,omitempty"`
+	IncomeLossFromContinuingOperationsBeforeTax                         DataPoint `json:"income_loss_from_continuing_operations_before_tax,omitempty"`
+	IncomeTaxExpenseBenefit                                             DataPoint `json:"income_tax_expense_benefit,omitempty"`
+	IncomeLossFromContinuingOperationsAfterTax                          DataPoint `json:"income_loss_from_continuing_operations_after_tax,omitempty"`
+	IncomeLossFromDiscontinuedOperationsNetOfTax                        DataPoint `json:"income_loss_from_discontinued_operations_net_of_tax,omitempty"`
+	NetIncomeLoss                                                       DataPoint `json:"net_income_loss,omitempty"`
+	NetIncomeLossAttributableToParent                                   DataPoint `json:"net_income_loss_attributable_to_parent,omitempty"`
+	NetIncomeLossAttributableToNoncontrollingInterest                   DataPoint `json:"net_income_loss_attributable_to_noncontrolling_interest,omitempty"`
+	PreferredStockDividendsAndOtherAdjustments                          DataPoint `json:"preferred_stock_dividends_and_other_adjustments,omitempty"`
+	ParticipatingSecuritiesDistributedAndUndistributedEarningsLossBasic DataPoint `json:"participating_securities_distributed_and_undistributed_earnings_loss_basic,omitempty"`
+	NetIncomeLossAvailableToCommonStockholdersBasic                     DataPoint `json:"net_income_loss_available_to_common_stockholders_basic,omitempty"`
+	BasicEarningsPerShare                                               DataPoint `json:"basic_earnings_per_share,omitempty"`
+	DilutedEarningsPerShare                                             DataPoint `json:"diluted_earnings_per_share,omitempty"`
+	BasicAverageShares                                                  DataPoint `json:"basic_average_shares,omitempty"`
+	DilutedAverageShares                                                DataPoint `json:"diluted_average_shares,omitempty"`
+}
+
+// BalanceSheet holds the known line items of a balance sheet. Line items that a filing doesn't report are zero, and
+// line items that aren't listed here are still in StockFinancial.Financials.
+type BalanceSheet struct {
+	Assets                                     DataPoint `json:"assets,omitempty"`
+	CurrentAssets                              DataPoint `json:"current_assets,omitempty"`
+	Inventory                                  DataPoint `json:"inventory,omitempty"`
+	OtherCurrentAssets                         DataPoint `json:"other_current_assets,omitempty"`
+	NoncurrentAssets                           DataPoint `json:"noncurrent_assets,omitempty"`
+	FixedAssets                                DataPoint `json:"fixed_assets,omitempty"`
+	IntangibleAssets                           DataPoint `json:"intangible_assets,omitempty"`
+	OtherThanFixedNoncurrentAssets             DataPoint `json:"other_than_fixed_noncurrent_assets,omitempty"`
+	Liabilities                                DataPoint `json:"liabilities,omitempty"`
+	CurrentLiabilities                         DataPoint `json:"current_liabilities,omitempty"`
+	AccountsPayable                            DataPoint `json:"accounts_payable,omitempty"`
+	OtherCurrentLiabilities                    DataPoint `json:"other_current_liabilities,omitempty"`
+	NoncurrentLiabilities                      DataPoint `json:"noncurrent_liabilities,omitempty"`
+	LongTermDebt                               DataPoint `json:"long_term_debt,omitempty"`
+	Equity                                     DataPoint `json:"equity,omitempty"`
+	EquityAttributableToParent                 DataPoint `json:"equity_attributable_to_parent,omitempty"`
+	EquityAttributableToNoncontrollingInterest DataPoint `json:"equity_attributable_to_noncontrolling_interest,omitempty"`
+	LiabilitiesAndEquity                       DataPoint `json:"liabilities_and_equity,omitempty"`
+}
+
+// CashFlowStatement holds the known line items of a cash flow statement. Line items that a filing doesn't report are
+// zero, and line items that aren't listed here are still in StockFinancial.Financials.
+type CashFlowStatement struct {
+	NetCashFlow                                  DataPoint `json:"net_cash_flow,omitempty"`
+	NetCashFlowContinuing                        DataPoint `json:"net_cash_flow_continuing,omitempty"`
+	NetCashFlowFromOperatingActivities           DataPoint `json:"net_cash_flow_from_operating_activities,omitempty"`
+	NetCashFlowFromOperatingActivitiesContinuing DataPoint `json:"net_cash_flow_from_operating_activities_continuing,omitempty"`
+	NetCashFlowFromInvestingActivities           DataPoint `json:"net_cash_flow_from_investing_activities,omitempty"`
+	NetCashFlowFromInvestingActivitiesContinuing DataPoint `json:"net_cash_flow_from_investing_activities_continuing,omitempty"`
+	NetCashFlowFromFinancingActivities           DataPoint `json:"net_cash_flow_from_financing_activities,omitempty"`
+	NetCashFlowFromFinancingActivitiesContinuing DataPoint `json:"net_cash_flow_from_financing_activities_continuing,omitempty"`
+	ExchangeGainsLosses                          DataPoint `json:"exchange_gains_losses,omitempty"`
+}
+
+// ComprehensiveIncome holds the known line items of a comprehensive income statement. Line items that a filing doesn't
+// report are zero, and line items that aren't listed here are still in StockFinancial.Financials.
+type ComprehensiveIncome struct {
+	ComprehensiveIncomeLoss                                     DataPoint `json:"comprehensive_income_loss,omitempty"`
+	ComprehensiveIncomeLossAttributableToParent                 DataPoint `json:"comprehensive_income_loss_attributable_to_parent,omitempty"`
+	ComprehensiveIncomeLossAttributableToNoncontrollingInterest DataPoint `json:"comprehensive_income_loss_attributable_to_noncontrolling_interest,omitempty"`
+	OtherComprehensiveIncomeLoss                                DataPoint `json:"other_comprehensive_income_loss,omitempty"`
+	OtherComprehensiveIncomeLossAttributableToParent            DataPoint `json:"other_comprehensive_income_loss_attributable_to_parent,omitempty"`
+}
@@ -1,10 +1,12 @@
 package models_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"cloud.google.com/go/civil"
 	"github.com/polygon-io/client-go/rest/models"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestListStockFinancialsParams(t *testing.T) {
@@ -65,3 +67,56 @@ func TestListStockFinancialsParams(t *testing.T) {
 
 	checkParams(t, expect, *actual)
 }
+
+func TestStockFinancial(t *testing.T) {
+	data := `{
+		"financials": {
+			"income_statement": {
+				"revenues": {"label": "Revenues", "value": 5.191e+08, "unit": "USD", "order": 100},
+				"diluted_earnings_per_share": {"label": "Diluted Earnings Per Share", "value": 1.33, "unit": "USD / shares", "order": 4300},
+				"some_new_line_item": {"label": "Some New Line Item", "value": 1, "unit": "USD", "order": 9999}
+			},
+			"balance_sheet": {
+				"assets": {"label": "Assets", "value": 3.4258e+09, "unit": "USD", "order": 100}
+			},
+			"cash_flow_statement": {
+				"net_cash_flow": {"label": "Net Cash Flow", "value": -1.15e+07, "unit": "USD", "order": 1100}
+			}
+		},
+		"start_date": "2022-01-01",
+		"end_date": "2022-04-03",
+		"filing_date": "2022-04-29",
+		"fiscal_period": "Q1",
+		"fiscal_year": "2022"
+	}`
+
+	var f models.StockFinancial
+	assert.Nil(t, json.Unmarshal([]byte(data), &f))
+	assert.Equal(t, civil.Date{Year: 2022, Month: 1, Day: 1}, f.StartDate)
+	assert.Equal(t, civil.Date{Year: 2022, Month: 4, Day: 3}, f.EndDate)
+	assert.Equal(t, civil.Date{Year: 2022, Month: 4, Day: 29}, f.FilingDate)
+	assert.Equal(t, 2022, f.FiscalYear)
+
+	assert.Equal(t, 5.191e+08, f.IncomeStatement().Revenues.Value)
+	assert.Equal(t, "USD / shares", f.IncomeStatement().DilutedEarningsPerShare.Unit)
+	assert.Equal(t, models.DataPoint{}, f.IncomeStatement().CostOfRevenue)
+	assert.Equal(t, 3.4258e+09, f.BalanceSheet().Assets.Value)
+	assert.Equal(t, -1.15e+07, f.CashFlowStatement().NetCashFlow.Value)
+	assert.Equal(t, models.ComprehensiveIncome{}, f.ComprehensiveIncome())
+
+	// unknown line items are still in the raw map
+	assert.Equal(t, 1.0, f.Financials[models.IncomeStatementKey]["some_new_line_item"].Value)
+
+	// round trips in the format of the API, and empty dates decode as zero
+	out, err := json.Marshal(f)
+	assert.Nil(t, err)
+	var again models.StockFinancial
+	assert.Nil(t, json.Unmarshal(out, &again))
+	assert.Equal(t, f, again)
+
+	var ttm models.StockFinancial
+	assert.Nil(t, json.Unmarshal([]byte(`{"fiscal_period": "TTM", "fiscal_year": "", "filing_date": ""}`), &ttm))
+	assert.True(t, ttm.FilingDate.IsZero())
+	assert.Equal(t, 0, ttm.FiscalYear)
+	assert.NotNil(t, json.Unmarshal([]byte(`{"fiscal_year": "FY22"}`), &ttm))
+}
@@ -0,0 +1,325 @@
+// Package news watches the news feed of ReferenceClient.ListTickerNews.
+//
+// The news endpoint is pull-only, so a Watcher polls it on an interval. Each poll only asks for articles published
+// since the newest one it has seen, and articles are de-duplicated by ID, so every article is emitted once even if it
+// mentions several watched tickers:
+//
+//	w := news.New(c, news.Config{
+//		Tickers:  []string{"AAPL", "MSFT"},
+//		Interval: 30 * time.Second,
+//	})
+//	go w.Run(ctx)
+//	for article := range w.Articles() {
+//		log.Print(article.Title, w.Sentiment("AAPL").Score)
+//	}
+//
+// The watcher also keeps rolling sentiment scores per ticker from the insights of the articles it has seen.
+package news
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/polygon-io/client-go/rest/iter"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+const (
+	defaultInterval = time.Minute
+	defaultWindow   = 24 * time.Hour
+	defaultBuffer   = 100
+
+	// pageSize is the largest page size of the news endpoint.
+	pageSize = 1000
+)
+
+// The sentiments of article insights.
+const (
+	Positive = "positive"
+	Negative = "negative"
+	Neutral  = "neutral"
+)
+
+// Lister lists ticker news. The Client of the rest package implements it.
+type Lister interface {
+	ListTickerNews(ctx context.Context, params *models.ListTickerNewsParams, options ...models.RequestOption) *iter.Iter[models.TickerNews]
+}
+
+// Config is a set of watcher options.
+type Config struct {
+	// Tickers limits the feed to articles that mention any of these tickers. Each ticker is a separate request per
+	// poll. Every article is watched if it's empty.
+	Tickers []string
+
+	// Interval is the time between polls. It defaults to a minute.
+	Interval time.Duration
+
+	// Since is the time from which articles are watched. It defaults to the time the watcher is created.
+	Since time.Time
+
+	// Window is the length of the rolling sentiment scores. It defaults to a day.
+	Window time.Duration
+
+	// OnArticle is an optional function that's called with every new article in the order they were published. The
+	// channel returned by Articles isn't used if it's set.
+	OnArticle func(models.TickerNews)
+
+	// OnError is an optional function that's called when a poll fails. The watcher keeps polling either way.
+	OnError func(error)
+
+	// Buffer is the size of the channel returned by Articles. It defaults to 100.
+	Buffer int
+}
+
+// Sentiment is the rolling sentiment of a ticker.
+type Sentiment struct {
+	Ticker   string
+	Positive int
+	Negative int
+	Neutral  int
+
+	// Score is the number of positive minus negative insights over the number of insights, from -1 to 1. It's zero
+	// without insights.
+	Score float64
+}
+
+// Watcher polls the news feed for new articles. It's safe for concurrent use.
+type Watcher struct {
+	client Lister
+	config Config
+	out    chan models.TickerNews
+
+	mtx sync.Mutex
+
+	// watermarks are the publication times of the newest articles per ticker filter, which is empty without one
+	watermarks map[string]time.Time
+
+	// seen are the IDs of the articles at or after the oldest watermark with their publication times
+	seen map[string]time.Time
+
+	// insights are the insights per ticker in the sentiment window
+	insights map[string][]insight
+	latest   time.Time
+}
+
+type insight struct {
+	published time.Time
+	sentiment string
+}
+
+// New creates a watcher.
+func New(client Lister, config Config) *Watcher {
+	if config.Interval <= 0 {
+		config.Interval = defaultInterval
+	}
+	if config.Window <= 0 {
+		config.Window = defaultWindow
+	}
+	if config.Buffer <= 0 {
+		config.Buffer = defaultBuffer
+	}
+	if config.Since.IsZero() {
+		config.Since = time.Now()
+	}
+
+	w := &Watcher{
+		client:     client,
+		config:     config,
+		out:        make(chan models.TickerNews, config.Buffer),
+		watermarks: make(map[string]time.Time),
+		seen:       make(map[string]time.Time),
+		insights:   make(map[string][]insight),
+	}
+	for _, f := range w.filters() {
+		w.watermarks[f] = config.Since
+	}
+	return w
+}
+
+// Articles returns the channel of new articles, which is closed when Run returns.
+func (w *Watcher) Articles() <-chan models.TickerNews {
+	return w.out
+}
+
+// Run polls the feed until the context is done, and returns its error.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.out)
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+	for {
+		articles, err := w.Poll(ctx)
+		if err != nil && w.config.OnError != nil && ctx.Err() == nil {
+			w.config.OnError(err)
+		}
+		for _, a := range articles {
+			if w.config.OnArticle != nil {
+				w.config.OnArticle(a)
+				continue
+			}
+			select {
+			case w.out <- a:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Poll polls the feed once and returns the new articles in the order they were published, without emitting them.
+// Articles from the filters that succeeded are returned even if another one failed.
+func (w *Watcher) Poll(ctx context.Context) ([]models.TickerNews, error) {
+	var articles []models.TickerNews
+	var firstErr error
+	for _, f := range w.filters() {
+		res, err := w.poll(ctx, f)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		articles = append(articles, res...)
+	}
+	sort.SliceStable(articles, func(i, j int) bool {
+		return time.Time(articles[i].PublishedUTC).Before(time.Time(articles[j].PublishedUTC))
+	})
+
+	w.mtx.Lock()
+	w.prune()
+	w.mtx.Unlock()
+	return articles, firstErr
+}
+
+// Sentiment returns the sentiment of a ticker over the window before the newest article the watcher has seen.
+func (w *Watcher) Sentiment(ticker string) Sentiment {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	s := Sentiment{Ticker: ticker}
+	for _, in := range w.insights[ticker] {
+		switch in.sentiment {
+		case Positive:
+			s.Positive++
+		case Negative:
+			s.Negative++
+		case Neutral:
+			s.Neutral++
+		}
+	}
+	if total := s.Positive + s.Negative + s.Neutral; total > 0 {
+		s.Score = float64(s.Positive-s.Negative) / float64(total)
+	}
+	return s
+}
+
+// Sentiments returns the sentiment of every ticker with insights in the window, sorted by ticker.
+func (w *Watcher) Sentiments() []Sentiment {
+	w.mtx.Lock()
+	tickers := make([]string, 0, len(w.insights))
+	for t := range w.insights {
+		tickers = append(tickers, t)
+	}
+	w.mtx.Unlock()
+
+	sort.Strings(tickers)
+	out := make([]Sentiment, 0, len(tickers))
+	for _, t := range tickers {
+		out = append(out, w.Sentiment(t))
+	}
+	return out
+}
+
+// poll lists the articles of a ticker filter since its watermark and records the ones that weren't seen yet.
+func (w *Watcher) poll(ctx context.Context, filter string) ([]models.TickerNews, error) {
+	w.mtx.Lock()
+	since := w.watermarks[filter]
+	w.mtx.Unlock()
+
+	// articles published at the watermark are listed again, since others can share its timestamp
+	params := models.ListTickerNewsParams{}.
+		WithPublishedUTC(models.GTE, models.Millis(since)).
+		WithSort(models.PublishedUTC).
+		WithOrder(models.Asc).
+		WithLimit(pageSize)
+	if filter != "" {
+		params = params.WithTicker(models.EQ, filter)
+	}
+
+	var out []models.TickerNews
+	it := w.client.ListTickerNews(ctx, params)
+	for it.Next() {
+		a := it.Item()
+		published := time.Time(a.PublishedUTC)
+
+		w.mtx.Lock()
+		if published.After(w.watermarks[filter]) {
+			w.watermarks[filter] = published
+		}
+		_, seen := w.seen[a.ID]
+		if !seen {
+			w.seen[a.ID] = published
+			w.record(a)
+		}
+		w.mtx.Unlock()
+
+		if !seen {
+			out = append(out, a)
+		}
+	}
+	return out, it.Err()
+}
+
+// record adds the insights of an article to the sentiment window. It must be called with the mutex held.
+func (w *Watcher) record(a models.TickerNews) {
+	published := time.Time(a.PublishedUTC)
+	for _, in := range a.Insights {
+		w.insights[in.Ticker] = append(w.insights[in.Ticker], insight{published: published, sentiment: in.Sentiment})
+	}
+	if published.After(w.latest) {
+		w.latest = published
+	}
+}
+
+// prune forgets the articles that can't be listed again and the insights that left the sentiment window. It must be
+// called with the mutex held.
+func (w *Watcher) prune() {
+	var oldest time.Time
+	for _, t := range w.watermarks {
+		if oldest.IsZero() || t.Before(oldest) {
+			oldest = t
+		}
+	}
+	for id, t := range w.seen {
+		if t.Before(oldest) {
+			delete(w.seen, id)
+		}
+	}
+
+	cutoff := w.latest.Add(-w.config.Window)
+	for ticker, ins := range w.insights {
+		kept := ins[:0]
+		for _, in := range ins {
+			if in.published.After(cutoff) {
+				kept = append(kept, in)
+			}
+		}
+		if len(kept) == 0 {
+			delete(w.insights, ticker)
+		} else {
+			w.insights[ticker] = kept
+		}
+	}
+}
+
+func (w *Watcher) filters() []string {
+	if len(w.config.Tickers) == 0 {
+		return []string{""}
+	}
+	return w.config.Tickers
+}
@@ -0,0 +1,94 @@
+package news_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/news"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func ids(articles []models.TickerNews) []string {
+	var out []string
+	for _, a := range articles {
+		out = append(out, a.ID)
+	}
+	return out
+}
+
+func TestPoll(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	w := news.New(s.Client("API_KEY"), news.Config{
+		Tickers: []string{"AAPL", "MSFT"},
+		Since:   time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+	})
+
+	// the article that mentions both tickers is only emitted once
+	articles, err := w.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a1", "a3", "a2"}, ids(articles))
+	assert.Equal(t, news.Sentiment{Ticker: "AAPL", Positive: 1, Negative: 1}, w.Sentiment("AAPL"))
+	assert.Equal(t, news.Sentiment{Ticker: "MSFT", Positive: 1, Neutral: 1, Score: 0.5}, w.Sentiment("MSFT"))
+
+	// the next poll starts at the watermarks and skips the articles published at them
+	articles, err = w.Poll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a4"}, ids(articles))
+	assert.Len(t, s.Requests(), 4)
+
+	// the older insights left the window
+	assert.Equal(t, []news.Sentiment{{Ticker: "AAPL", Positive: 1, Score: 1}}, w.Sentiments())
+	assert.Equal(t, news.Sentiment{Ticker: "MSFT"}, w.Sentiment("MSFT"))
+}
+
+func TestRun(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	w := news.New(s.Client("API_KEY"), news.Config{
+		Tickers:  []string{"AAPL", "MSFT"},
+		Since:    time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+		Interval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	var got []models.TickerNews
+	for a := range w.Articles() {
+		got = append(got, a)
+		if len(got) == 4 {
+			cancel()
+		}
+	}
+	assert.Equal(t, []string{"a1", "a3", "a2", "a4"}, ids(got))
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestRunError(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// there's no fixture for the unfiltered feed
+	var errs []error
+	w := news.New(s.Client("API_KEY"), news.Config{
+		Since:     time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC),
+		OnArticle: func(models.TickerNews) { t.Error("unexpected article") },
+		OnError: func(err error) {
+			errs = append(errs, err)
+			cancel()
+		},
+	})
+	assert.ErrorIs(t, w.Run(ctx), context.Canceled)
+	assert.Len(t, errs, 1)
+}
@@ -0,0 +1,94 @@
+package options
+
+import "math"
+
+// tree is a Cox-Ross-Rubinstein binomial tree for an American contract. Besides the value at the root, it keeps the
+// node values of the first two steps so that delta, gamma, and theta can be read off the tree.
+type tree struct {
+	c    Contract
+	vol  float64
+	dt   float64
+	u    float64
+	root float64
+
+	step1 [2]float64
+	step2 [3]float64
+}
+
+func newTree(c Contract, vol float64) *tree {
+	steps := c.Steps
+	if steps <= 0 {
+		steps = DefaultSteps
+	}
+	if steps < 2 {
+		steps = 2
+	}
+	if vol <= 0 {
+		vol = 1e-8
+	}
+
+	t := &tree{c: c, vol: vol, dt: c.Expiry / float64(steps)}
+	t.u = math.Exp(vol * math.Sqrt(t.dt))
+	d := 1 / t.u
+	df := math.Exp(-c.Rate * t.dt)
+	p := (math.Exp((c.Rate-c.DividendYield)*t.dt) - d) / (t.u - d)
+	p = math.Min(math.Max(p, 0), 1)
+
+	values := make([]float64, steps+1)
+	for i := 0; i <= steps; i++ {
+		values[i] = c.intrinsic(c.Spot * math.Pow(t.u, float64(2*i-steps)))
+	}
+	if steps == 2 {
+		// the backward induction starts a step in, so the terminal nodes are the nodes two steps in
+		copy(t.step2[:], values)
+	}
+
+	for n := steps - 1; n >= 0; n-- {
+		for i := 0; i <= n; i++ {
+			cont := df * (p*values[i+1] + (1-p)*values[i])
+			values[i] = math.Max(cont, c.intrinsic(c.Spot*math.Pow(t.u, float64(2*i-n))))
+		}
+		switch n {
+		case 2:
+			copy(t.step2[:], values[:3])
+		case 1:
+			copy(t.step1[:], values[:2])
+		}
+	}
+	t.root = values[0]
+
+	return t
+}
+
+func (t *tree) price() float64 {
+	return t.root
+}
+
+func treeGreeks(c Contract, vol float64) Greeks {
+	t := newTree(c, vol)
+	s, u := c.Spot, t.u
+
+	var g Greeks
+	g.Delta = (t.step1[1] - t.step1[0]) / (s*u - s/u)
+
+	up := (t.step2[2] - t.step2[1]) / (s*u*u - s)
+	down := (t.step2[1] - t.step2[0]) / (s - s/(u*u))
+	g.Gamma = (up - down) / ((s*u*u - s/(u*u)) / 2)
+
+	// the middle node two steps in has the same spot as the root
+	g.Theta = (t.step2[1] - t.root) / (2 * t.dt) / 365
+
+	// vega and rho are bumped since the tree doesn't expose them directly
+	const dv, dr = 0.01, 0.0001
+	bumped := c
+	lv := math.Max(vol-dv, 1e-8)
+	g.Vega = (newTree(c, vol+dv).price() - newTree(c, lv).price()) / (vol + dv - lv) / 100
+
+	bumped.Rate = c.Rate + dr
+	hi := newTree(bumped, vol).price()
+	bumped.Rate = c.Rate - dr
+	lo := newTree(bumped, vol).price()
+	g.Rho = (hi - lo) / (2 * dr) / 100
+
+	return g
+}
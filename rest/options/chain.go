@@ -0,0 +1,354 @@
+package options
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/iter"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// Pair is the call and put of an option chain that share an expiration and a strike. Either side may be nil if the
+// chain only lists one of them.
+type Pair struct {
+	Expiration civil.Date
+	Strike     float64
+	Call       *models.OptionContractSnapshot
+	Put        *models.OptionContractSnapshot
+}
+
+// ParityDeviation is the difference between the observed and the theoretical call-put spread of a pair. Under
+// put-call parity, C - P = S*exp(-qT) - K*exp(-rT) for European contracts.
+type ParityDeviation struct {
+	Expiration civil.Date
+	Strike     float64
+	CallPrice  float64
+	PutPrice   float64
+	Expected   float64
+	Deviation  float64
+}
+
+// OptionsChain indexes the contracts of an underlying by expiration and strike. It is safe for concurrent use, so a
+// chain can be refreshed in place while strategies read from it.
+type OptionsChain struct {
+	mtx        sync.RWMutex
+	underlying string
+	spot       float64
+	pairs      map[civil.Date]map[float64]*Pair
+}
+
+// NewOptionsChain returns an empty chain for the given underlying ticker.
+func NewOptionsChain(underlying string) *OptionsChain {
+	return &OptionsChain{
+		underlying: underlying,
+		pairs:      make(map[civil.Date]map[float64]*Pair),
+	}
+}
+
+// Update consumes a ListOptionsChainSnapshot iterator and inserts or replaces every contract it yields. Contracts
+// that aren't returned are left untouched, so filtered snapshot calls can be used to update part of the chain.
+func (c *OptionsChain) Update(it *iter.Iter[models.OptionContractSnapshot]) error {
+	snaps, err := drain(it)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for i := range snaps {
+		c.put(snaps[i])
+	}
+	return nil
+}
+
+// Refresh consumes a ListOptionsChainSnapshot iterator and replaces the chain with the contracts it yields, removing
+// contracts that are no longer listed. If the iterator fails the chain is left unchanged.
+func (c *OptionsChain) Refresh(it *iter.Iter[models.OptionContractSnapshot]) error {
+	snaps, err := drain(it)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.pairs = make(map[civil.Date]map[float64]*Pair)
+	for i := range snaps {
+		c.put(snaps[i])
+	}
+	return nil
+}
+
+// UpdateContracts consumes a ListOptionsContracts iterator and adds the listed contracts without market data.
+// Contracts that already have a snapshot in the chain keep it.
+func (c *OptionsChain) UpdateContracts(it *iter.Iter[models.OptionsContract]) error {
+	contracts, err := drain(it)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, oc := range contracts {
+		if p := c.pairs[oc.ExpirationDate][oc.StrikePrice]; p != nil && p.side(models.ContractType(oc.ContractType)) != nil {
+			continue
+		}
+		c.put(models.OptionContractSnapshot{
+			Details: models.OptionDetails{
+				ContractType:      oc.ContractType,
+				ExerciseStyle:     oc.ExerciseStyle,
+				ExpirationDate:    oc.ExpirationDate,
+				SharesPerContract: oc.SharesPerContract,
+				StrikePrice:       oc.StrikePrice,
+				Ticker:            oc.Ticker,
+			},
+			UnderlyingAsset: models.UnderlyingAsset{Ticker: oc.UnderlyingTicker},
+		})
+	}
+	return nil
+}
+
+// Add inserts or replaces a single contract snapshot.
+func (c *OptionsChain) Add(snap models.OptionContractSnapshot) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.put(snap)
+}
+
+// Underlying returns the underlying ticker of the chain.
+func (c *OptionsChain) Underlying() string {
+	return c.underlying
+}
+
+// Spot returns the most recent underlying price seen in the snapshots, or zero if there wasn't one.
+func (c *OptionsChain) Spot() float64 {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.spot
+}
+
+// Expirations returns the expiration dates of the chain in ascending order.
+func (c *OptionsChain) Expirations() []civil.Date {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.expirations()
+}
+
+// Strikes returns the strikes listed for an expiration in ascending order.
+func (c *OptionsChain) Strikes(expiration civil.Date) []float64 {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.strikes(expiration)
+}
+
+// Pair returns a copy of the call and put at an expiration and strike.
+func (c *OptionsChain) Pair(expiration civil.Date, strike float64) (Pair, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	p, ok := c.pairs[expiration][strike]
+	if !ok {
+		return Pair{}, false
+	}
+	return p.clone(), true
+}
+
+// Pairs returns copies of every call and put pair of an expiration in ascending strike order.
+func (c *OptionsChain) Pairs(expiration civil.Date) []Pair {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	strikes := c.strikes(expiration)
+	pairs := make([]Pair, 0, len(strikes))
+	for _, k := range strikes {
+		pairs = append(pairs, c.pairs[expiration][k].clone())
+	}
+	return pairs
+}
+
+// Contracts returns copies of every contract in the chain ordered by expiration, strike, and type (calls first).
+func (c *OptionsChain) Contracts() []models.OptionContractSnapshot {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	var out []models.OptionContractSnapshot
+	for _, exp := range c.expirations() {
+		for _, k := range c.strikes(exp) {
+			p := c.pairs[exp][k]
+			if p.Call != nil {
+				out = append(out, *p.Call)
+			}
+			if p.Put != nil {
+				out = append(out, *p.Put)
+			}
+		}
+	}
+	return out
+}
+
+// NearestStrike returns the listed strike of an expiration closest to the given price. Ties go to the lower strike.
+func (c *OptionsChain) NearestStrike(expiration civil.Date, price float64) (float64, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	best, found := 0.0, false
+	for _, k := range c.strikes(expiration) {
+		if !found || math.Abs(k-price) < math.Abs(best-price) {
+			best, found = k, true
+		}
+	}
+	return best, found
+}
+
+// ATMStrike returns the listed strike of an expiration closest to the current underlying price.
+func (c *OptionsChain) ATMStrike(expiration civil.Date) (float64, bool) {
+	spot := c.Spot()
+	if spot <= 0 {
+		return 0, false
+	}
+	return c.NearestStrike(expiration, spot)
+}
+
+// NearestExpiration returns the expiration closest to the given number of calendar days after from. Expirations
+// before from are ignored, and ties go to the earlier expiration.
+func (c *OptionsChain) NearestExpiration(from civil.Date, days int) (civil.Date, bool) {
+	target := from.AddDays(days)
+
+	best, found := civil.Date{}, false
+	for _, d := range c.Expirations() {
+		if d.Before(from) {
+			continue
+		}
+		if !found || absDays(d.DaysSince(target)) < absDays(best.DaysSince(target)) {
+			best, found = d, true
+		}
+	}
+	return best, found
+}
+
+// ParityDeviations compares the call-put spread of every pair of an expiration with both sides priced against
+// put-call parity. Prices come from MarketPrice, the spot from the chain, and the time to expiration is measured to
+// the 4pm close in cfg.Location. The relation is exact for European contracts only; American contracts deviate by
+// their early exercise premium.
+func (c *OptionsChain) ParityDeviations(expiration civil.Date, cfg Config) []ParityDeviation {
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	loc := cfg.Location
+	if loc == nil {
+		loc = tz.NewYork()
+	}
+	t := YearsUntil(now, time.Date(expiration.Year, expiration.Month, expiration.Day, 16, 0, 0, 0, loc))
+	spot := c.Spot()
+	if t <= 0 || spot <= 0 {
+		return nil
+	}
+
+	var out []ParityDeviation
+	for _, p := range c.Pairs(expiration) {
+		if p.Call == nil || p.Put == nil {
+			continue
+		}
+		cp, cok := MarketPrice(*p.Call)
+		pp, pok := MarketPrice(*p.Put)
+		if !cok || !pok {
+			continue
+		}
+		expected := spot*math.Exp(-cfg.DividendYield*t) - p.Strike*math.Exp(-cfg.Rate*t)
+		out = append(out, ParityDeviation{
+			Expiration: expiration,
+			Strike:     p.Strike,
+			CallPrice:  cp,
+			PutPrice:   pp,
+			Expected:   expected,
+			Deviation:  cp - pp - expected,
+		})
+	}
+	return out
+}
+
+func (c *OptionsChain) put(snap models.OptionContractSnapshot) {
+	d := snap.Details
+	typ := models.ContractType(d.ContractType)
+	if typ != models.ContractCall && typ != models.ContractPut {
+		return
+	}
+
+	if snap.UnderlyingAsset.Price > 0 {
+		c.spot = snap.UnderlyingAsset.Price
+	}
+
+	strikes, ok := c.pairs[d.ExpirationDate]
+	if !ok {
+		strikes = make(map[float64]*Pair)
+		c.pairs[d.ExpirationDate] = strikes
+	}
+	p, ok := strikes[d.StrikePrice]
+	if !ok {
+		p = &Pair{Expiration: d.ExpirationDate, Strike: d.StrikePrice}
+		strikes[d.StrikePrice] = p
+	}
+
+	if typ == models.ContractCall {
+		p.Call = &snap
+	} else {
+		p.Put = &snap
+	}
+}
+
+func (c *OptionsChain) expirations() []civil.Date {
+	dates := make([]civil.Date, 0, len(c.pairs))
+	for d := range c.pairs {
+		dates = append(dates, d)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+func (c *OptionsChain) strikes(expiration civil.Date) []float64 {
+	strikes := make([]float64, 0, len(c.pairs[expiration]))
+	for k := range c.pairs[expiration] {
+		strikes = append(strikes, k)
+	}
+	sort.Float64s(strikes)
+	return strikes
+}
+
+func (p *Pair) side(typ models.ContractType) *models.OptionContractSnapshot {
+	if typ == models.ContractCall {
+		return p.Call
+	}
+	return p.Put
+}
+
+func (p *Pair) clone() Pair {
+	out := Pair{Expiration: p.Expiration, Strike: p.Strike}
+	if p.Call != nil {
+		call := *p.Call
+		out.Call = &call
+	}
+	if p.Put != nil {
+		put := *p.Put
+		out.Put = &put
+	}
+	return out
+}
+
+func drain[T any](it *iter.Iter[T]) ([]T, error) {
+	var items []T
+	for it.Next() {
+		items = append(items, it.Item())
+	}
+	return items, it.Err()
+}
+
+func absDays(d int) int {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
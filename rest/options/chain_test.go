@@ -0,0 +1,148 @@
+package options_test
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/jarcoal/httpmock"
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/options"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	jan19 = civil.Date{Year: 2024, Month: 1, Day: 19}
+	feb16 = civil.Date{Year: 2024, Month: 2, Day: 16}
+)
+
+func snapshot(exp civil.Date, typ string, strike, bid, ask, spot float64) models.OptionContractSnapshot {
+	return models.OptionContractSnapshot{
+		Details: models.OptionDetails{
+			ContractType:   typ,
+			ExerciseStyle:  "american",
+			ExpirationDate: exp,
+			StrikePrice:    strike,
+			Ticker:         "O:AAPL" + exp.String() + typ,
+		},
+		LastQuote:       models.LastQuoteOptionContractSnapshot{Bid: bid, Ask: ask},
+		UnderlyingAsset: models.UnderlyingAsset{Price: spot, Ticker: "AAPL"},
+	}
+}
+
+func registerChain(t *testing.T, url string, snaps ...models.OptionContractSnapshot) {
+	registerResponder(t, url, models.ListOptionsChainSnapshotResponse{
+		BaseResponse: models.BaseResponse{Status: "OK", RequestID: "req"},
+		Results:      snaps,
+	})
+}
+
+func registerResponder(t *testing.T, url string, res any) {
+	body, err := json.Marshal(res)
+	assert.Nil(t, err)
+	httpmock.RegisterResponder(http.MethodGet, url, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewBytesResponse(200, body)
+		resp.Header.Add("Content-Type", "application/json")
+		return resp, nil
+	})
+}
+
+func TestOptionsChain(t *testing.T) {
+	c := polygon.New("API_KEY")
+	httpmock.ActivateNonDefault(c.HTTP.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	registerChain(t, "https://api.polygon.io/v3/snapshot/options/AAPL",
+		snapshot(jan19, "call", 180, 6.9, 7.1, 185),
+		snapshot(jan19, "put", 180, 1.9, 2.1, 185),
+		snapshot(jan19, "call", 190, 2.4, 2.6, 185),
+		snapshot(jan19, "put", 190, 7.3, 7.5, 185),
+		snapshot(feb16, "call", 185, 5.0, 5.2, 185),
+	)
+
+	chain := options.NewOptionsChain("AAPL")
+	err := chain.Update(c.ListOptionsChainSnapshot(context.Background(), &models.ListOptionsChainParams{UnderlyingAsset: "AAPL"}))
+	assert.Nil(t, err)
+
+	assert.Equal(t, []civil.Date{jan19, feb16}, chain.Expirations())
+	assert.Equal(t, []float64{180, 190}, chain.Strikes(jan19))
+	assert.Equal(t, 185.0, chain.Spot())
+	assert.Len(t, chain.Contracts(), 5)
+
+	p, ok := chain.Pair(jan19, 180)
+	assert.True(t, ok)
+	assert.Equal(t, "call", p.Call.Details.ContractType)
+	assert.Equal(t, "put", p.Put.Details.ContractType)
+
+	p, ok = chain.Pair(feb16, 185)
+	assert.True(t, ok)
+	assert.Nil(t, p.Put)
+
+	k, ok := chain.ATMStrike(jan19)
+	assert.True(t, ok)
+	assert.Equal(t, 180.0, k) // ties go to the lower strike
+
+	k, ok = chain.NearestStrike(jan19, 188)
+	assert.True(t, ok)
+	assert.Equal(t, 190.0, k)
+
+	exp, ok := chain.NearestExpiration(civil.Date{Year: 2024, Month: 1, Day: 2}, 40)
+	assert.True(t, ok)
+	assert.Equal(t, feb16, exp)
+
+	exp, ok = chain.NearestExpiration(civil.Date{Year: 2024, Month: 1, Day: 2}, 7)
+	assert.True(t, ok)
+	assert.Equal(t, jan19, exp)
+
+	// expirations that already passed aren't returned
+	exp, ok = chain.NearestExpiration(civil.Date{Year: 2024, Month: 2, Day: 1}, -30)
+	assert.True(t, ok)
+	assert.Equal(t, feb16, exp)
+	_, ok = chain.NearestExpiration(civil.Date{Year: 2024, Month: 3, Day: 1}, 0)
+	assert.False(t, ok)
+
+	devs := chain.ParityDeviations(jan19, options.Config{Rate: 0.05, Now: time.Date(2024, 1, 19, 16, 0, 0, 0, time.UTC).AddDate(0, 0, -1), Location: time.UTC})
+	assert.Len(t, devs, 2)
+	assert.InDelta(t, 7-2-(185-180*math.Exp(-0.05/365)), devs[0].Deviation, 1e-3)
+
+	// refreshing replaces the contracts in place
+	httpmock.Reset()
+	registerChain(t, "https://api.polygon.io/v3/snapshot/options/AAPL",
+		snapshot(jan19, "call", 180, 7.9, 8.1, 186),
+	)
+	err = chain.Refresh(c.ListOptionsChainSnapshot(context.Background(), &models.ListOptionsChainParams{UnderlyingAsset: "AAPL"}))
+	assert.Nil(t, err)
+	assert.Equal(t, []civil.Date{jan19}, chain.Expirations())
+	assert.Equal(t, 186.0, chain.Spot())
+	p, _ = chain.Pair(jan19, 180)
+	assert.Equal(t, 7.9, p.Call.LastQuote.Bid)
+	assert.Nil(t, p.Put)
+}
+
+func TestOptionsChainContracts(t *testing.T) {
+	c := polygon.New("API_KEY")
+	httpmock.ActivateNonDefault(c.HTTP.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	registerResponder(t, "https://api.polygon.io/v3/reference/options/contracts?underlying_ticker=AAPL", models.ListOptionsContractsResponse{
+		Results: []models.OptionsContract{
+			{ContractType: "call", ExpirationDate: jan19, StrikePrice: 180, Ticker: "O:AAPL240119C00180000", UnderlyingTicker: "AAPL"},
+			{ContractType: "put", ExpirationDate: jan19, StrikePrice: 180, Ticker: "O:AAPL240119P00180000", UnderlyingTicker: "AAPL"},
+		},
+	})
+
+	chain := options.NewOptionsChain("AAPL")
+	chain.Add(snapshot(jan19, "call", 180, 6.9, 7.1, 185))
+	err := chain.UpdateContracts(c.ListOptionsContracts(context.Background(), models.ListOptionsContractsParams{}.WithUnderlyingTicker(models.EQ, "AAPL")))
+	assert.Nil(t, err)
+
+	p, ok := chain.Pair(jan19, 180)
+	assert.True(t, ok)
+	assert.Equal(t, 6.9, p.Call.LastQuote.Bid) // the existing snapshot is kept
+	assert.Equal(t, "O:AAPL240119P00180000", p.Put.Details.Ticker)
+}
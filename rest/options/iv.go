@@ -0,0 +1,62 @@
+package options
+
+import "math"
+
+const (
+	minVol       = 1e-4
+	maxVol       = 5.0
+	ivTolerance  = 1e-8
+	ivIterations = 100
+)
+
+// ImpliedVolatility returns the volatility that reproduces the given option price. European contracts are solved with
+// Newton's method safeguarded by bisection; American contracts are solved by bisection on the binomial price.
+func ImpliedVolatility(c Contract, price float64) (float64, error) {
+	if err := c.validate(); err != nil {
+		return 0, err
+	}
+
+	value := func(vol float64) float64 {
+		if c.Style == American {
+			return newTree(c, vol).price()
+		}
+		return bsPrice(c, vol)
+	}
+
+	lo, hi := minVol, maxVol
+	flo, fhi := value(lo)-price, value(hi)-price
+	if flo > ivTolerance || fhi < -ivTolerance {
+		return 0, ErrNoSolution
+	}
+	if math.Abs(flo) <= ivTolerance {
+		return lo, nil
+	}
+
+	vol := 0.3
+	for i := 0; i < ivIterations; i++ {
+		f := value(vol) - price
+		if math.Abs(f) <= ivTolerance {
+			return vol, nil
+		}
+		if f < 0 {
+			lo = vol
+		} else {
+			hi = vol
+		}
+
+		next := (lo + hi) / 2
+		if c.Style != American {
+			if vega := bsGreeks(c, vol).Vega * 100; vega > 1e-12 {
+				if n := vol - f/vega; n > lo && n < hi {
+					next = n
+				}
+			}
+		}
+		if hi-lo < 1e-12 {
+			return next, nil
+		}
+		vol = next
+	}
+
+	return vol, nil
+}
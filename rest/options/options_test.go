@@ -0,0 +1,139 @@
+package options_test
+
+import (
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/options"
+	"github.com/stretchr/testify/assert"
+)
+
+func contract(t models.ContractType, style options.ExerciseStyle) options.Contract {
+	return options.Contract{Type: t, Style: style, Strike: 100, Expiry: 1, Spot: 100, Rate: 0.05}
+}
+
+func TestBlackScholes(t *testing.T) {
+	call, err := options.Price(contract(models.ContractCall, options.European), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 10.4506, call, 1e-4)
+
+	put, err := options.Price(contract(models.ContractPut, options.European), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 5.5735, put, 1e-4)
+
+	g, err := options.ComputeGreeks(contract(models.ContractCall, options.European), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.6368, g.Delta, 1e-4)
+	assert.InDelta(t, 0.01876, g.Gamma, 1e-5)
+	assert.InDelta(t, 0.3752, g.Vega, 1e-4)
+	assert.InDelta(t, -6.4140/365, g.Theta, 1e-5)
+	assert.InDelta(t, 0.5323, g.Rho, 1e-4)
+
+	g, err = options.ComputeGreeks(contract(models.ContractPut, options.European), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, -0.3632, g.Delta, 1e-4)
+	assert.InDelta(t, -0.4189, g.Rho, 1e-4)
+}
+
+func TestBinomial(t *testing.T) {
+	// an American put is worth more than its European counterpart because of early exercise
+	put, err := options.Price(contract(models.ContractPut, options.American), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 6.09, put, 0.01)
+
+	// an American call on a non-dividend paying stock is never exercised early
+	call, err := options.Price(contract(models.ContractCall, options.American), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 10.4506, call, 0.02)
+
+	g, err := options.ComputeGreeks(contract(models.ContractCall, options.American), 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.6368, g.Delta, 0.005)
+	assert.InDelta(t, 0.01876, g.Gamma, 0.0005)
+	assert.InDelta(t, 0.3752, g.Vega, 0.005)
+	assert.InDelta(t, -6.4140/365, g.Theta, 0.0005)
+	assert.InDelta(t, 0.5323, g.Rho, 0.005)
+}
+
+func TestBinomialTwoSteps(t *testing.T) {
+	// only the up-up node of the tree is in the money, so the greeks can be worked out by hand
+	c := contract(models.ContractCall, options.American)
+	c.Steps = 2
+	price, err := options.Price(c, 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 9.5405, price, 1e-4)
+
+	g, err := options.ComputeGreeks(c, 0.2)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.6223, g.Delta, 1e-4)
+	assert.InDelta(t, 0.034888, g.Gamma, 1e-6)
+	assert.InDelta(t, -9.5405/365, g.Theta, 1e-6)
+}
+
+func TestImpliedVolatility(t *testing.T) {
+	for _, style := range []options.ExerciseStyle{options.European, options.American} {
+		for _, typ := range []models.ContractType{models.ContractCall, models.ContractPut} {
+			c := contract(typ, style)
+			price, err := options.Price(c, 0.35)
+			assert.Nil(t, err)
+			iv, err := options.ImpliedVolatility(c, price)
+			assert.Nil(t, err)
+			assert.InDelta(t, 0.35, iv, 1e-5)
+		}
+	}
+
+	// below intrinsic value
+	c := contract(models.ContractPut, options.American)
+	c.Spot = 80
+	_, err := options.ImpliedVolatility(c, 15)
+	assert.ErrorIs(t, err, options.ErrNoSolution)
+
+	// expired
+	c.Expiry = 0
+	_, err = options.ImpliedVolatility(c, 15)
+	assert.ErrorIs(t, err, options.ErrExpired)
+}
+
+func TestFill(t *testing.T) {
+	now := time.Date(2024, 1, 2, 16, 0, 0, 0, time.UTC)
+	cfg := options.Config{Rate: 0.05, Now: now, Location: time.UTC}
+
+	c := options.Contract{Type: models.ContractCall, Strike: 100, Expiry: 1, Spot: 100, Rate: 0.05}
+	price, err := options.Price(c, 0.25)
+	assert.Nil(t, err)
+
+	snap := models.OptionContractSnapshot{
+		Details: models.OptionDetails{
+			ContractType:   "call",
+			ExerciseStyle:  "european",
+			ExpirationDate: civil.DateOf(now.AddDate(0, 0, 365)),
+			StrikePrice:    100,
+		},
+		LastQuote:       models.LastQuoteOptionContractSnapshot{Bid: price - 0.05, Ask: price + 0.05},
+		UnderlyingAsset: models.UnderlyingAsset{Price: 100},
+	}
+
+	g, err := options.Fill(&snap, cfg)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.25, snap.ImpliedVolatility, 1e-6)
+	assert.Equal(t, g.Delta, snap.Greeks.Delta)
+	assert.NotZero(t, g.Rho)
+
+	// server values are kept unless overwriting
+	snap.Greeks.Delta = 0.5
+	_, err = options.Fill(&snap, cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, snap.Greeks.Delta)
+
+	cfg.Overwrite = true
+	_, err = options.Fill(&snap, cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, g.Delta, snap.Greeks.Delta)
+
+	// no price to imply volatility from
+	empty := models.OptionContractSnapshot{Details: snap.Details, UnderlyingAsset: snap.UnderlyingAsset}
+	_, err = options.Fill(&empty, options.Config{Now: now, Location: time.UTC})
+	assert.ErrorIs(t, err, options.ErrInvalidInputs)
+}
@@ -0,0 +1,116 @@
+package options
+
+import (
+	"time"
+
+	"github.com/polygon-io/client-go/rest/internal/tz"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// Config is the set of market assumptions used to value option chain snapshots.
+type Config struct {
+	// Rate is the continuously compounded risk-free rate, e.g. 0.05 for 5%.
+	Rate float64
+
+	// DividendYield is the continuous dividend yield of the underlying, e.g. 0.01 for 1%.
+	DividendYield float64
+
+	// Now is the valuation time. Omitting this uses the current time.
+	Now time.Time
+
+	// Steps is the number of binomial tree steps used for American contracts. Omitting this uses DefaultSteps.
+	Steps int
+
+	// Location is the time zone of the 4pm expiration close. Omitting this uses America/New_York.
+	Location *time.Location
+
+	// Overwrite recomputes the implied volatility and greeks even if the server returned them.
+	Overwrite bool
+}
+
+// ContractFromSnapshot builds the valuation inputs for a snapshot from its details and underlying price.
+func ContractFromSnapshot(snap models.OptionContractSnapshot, cfg Config) (Contract, error) {
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	loc := cfg.Location
+	if loc == nil {
+		loc = tz.NewYork()
+	}
+
+	exp := snap.Details.ExpirationDate
+	if !exp.IsValid() {
+		return Contract{}, ErrInvalidInputs
+	}
+	expiration := time.Date(exp.Year, exp.Month, exp.Day, 16, 0, 0, 0, loc)
+
+	style := European
+	if ExerciseStyle(snap.Details.ExerciseStyle) == American {
+		style = American
+	}
+
+	c := Contract{
+		Type:          models.ContractType(snap.Details.ContractType),
+		Style:         style,
+		Strike:        snap.Details.StrikePrice,
+		Expiry:        YearsUntil(now, expiration),
+		Spot:          snap.UnderlyingAsset.Price,
+		Rate:          cfg.Rate,
+		DividendYield: cfg.DividendYield,
+		Steps:         cfg.Steps,
+	}
+	return c, c.validate()
+}
+
+// MarketPrice returns the price used to imply volatility from a snapshot: the quote midpoint if there is a two-sided
+// quote, otherwise the last trade price.
+func MarketPrice(snap models.OptionContractSnapshot) (float64, bool) {
+	q := snap.LastQuote
+	if q.Midpoint > 0 {
+		return q.Midpoint, true
+	}
+	if q.Bid > 0 && q.Ask > 0 {
+		return (q.Bid + q.Ask) / 2, true
+	}
+	if snap.LastTrade.Price > 0 {
+		return snap.LastTrade.Price, true
+	}
+	return 0, false
+}
+
+// Fill computes the implied volatility and greeks of a snapshot in place if they are missing, or always if
+// cfg.Overwrite is set. It returns the full set of greeks, including rho which the snapshot model doesn't carry.
+func Fill(snap *models.OptionContractSnapshot, cfg Config) (Greeks, error) {
+	c, err := ContractFromSnapshot(*snap, cfg)
+	if err != nil {
+		return Greeks{}, err
+	}
+
+	if snap.ImpliedVolatility == 0 || cfg.Overwrite {
+		price, ok := MarketPrice(*snap)
+		if !ok {
+			return Greeks{}, ErrInvalidInputs
+		}
+		iv, err := ImpliedVolatility(c, price)
+		if err != nil {
+			return Greeks{}, err
+		}
+		snap.ImpliedVolatility = iv
+	}
+
+	g, err := ComputeGreeks(c, snap.ImpliedVolatility)
+	if err != nil {
+		return Greeks{}, err
+	}
+	if snap.Greeks == (models.Greeks{}) || cfg.Overwrite {
+		snap.Greeks = models.Greeks{
+			Delta: g.Delta,
+			Gamma: g.Gamma,
+			Theta: g.Theta,
+			Vega:  g.Vega,
+		}
+	}
+
+	return g, nil
+}
@@ -0,0 +1,392 @@
+package options
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// SmileMethod is how the implied volatilities of an expiration are interpolated across strikes.
+type SmileMethod int
+
+const (
+	// SVI fits the raw SVI parameterization to the total implied variance of each expiration. Expirations with fewer
+	// quotes than there are SVI parameters fall back to Linear.
+	SVI SmileMethod = iota
+
+	// Linear interpolates total implied variance linearly in log-moneyness between quotes and extrapolates it flat.
+	Linear
+)
+
+// ArbitrageKind is the kind of static arbitrage found in a volatility surface.
+type ArbitrageKind string
+
+const (
+	// CalendarArbitrage is total implied variance that decreases with time to expiration at a fixed moneyness.
+	CalendarArbitrage ArbitrageKind = "calendar"
+
+	// ButterflyArbitrage is a call price that isn't convex in strike, i.e. a butterfly spread with a negative price.
+	ButterflyArbitrage ArbitrageKind = "butterfly"
+)
+
+// minSVIPoints is the number of quotes needed to fit the five SVI parameters.
+const minSVIPoints = 5
+
+// SmilePoint is an implied volatility quote of a smile.
+type SmilePoint struct {
+	Strike float64
+	// Moneyness is the log of the strike over the forward.
+	Moneyness float64
+	Vol       float64
+	Type      models.ContractType
+}
+
+// Smile is the implied volatility of a single expiration as a function of strike.
+type Smile struct {
+	Expiration civil.Date
+	// Expiry is the time to expiration in years.
+	Expiry  float64
+	Forward float64
+	// Points are the quotes the smile was built from in ascending strike order.
+	Points []SmilePoint
+	// SVI holds the fitted parameters, or nil if the smile is interpolated linearly.
+	SVI *SVIParams
+}
+
+// TermPoint is the at-the-money forward implied volatility of an expiration.
+type TermPoint struct {
+	Expiration civil.Date
+	Expiry     float64
+	Forward    float64
+	ATMVol     float64
+}
+
+// Skew is the implied volatility of the at-the-money forward and of the call and put with a given delta for an
+// expiration, and the risk reversal and butterfly quoted from them.
+type Skew struct {
+	Expiration   civil.Date
+	Expiry       float64
+	ATMVol       float64
+	CallStrike   float64
+	CallVol      float64
+	PutStrike    float64
+	PutVol       float64
+	RiskReversal float64
+	Butterfly    float64
+}
+
+// Violation is a static arbitrage found in a volatility surface.
+type Violation struct {
+	Kind       ArbitrageKind
+	Expiration civil.Date
+	Strike     float64
+	// Amount is the size of the violation: the decrease in total variance for calendar arbitrage and the negative
+	// butterfly price per unit of forward for butterfly arbitrage.
+	Amount float64
+}
+
+// Surface is an implied volatility surface built from option chain snapshots. Each expiration is a Smile in total
+// implied variance, and volatilities between expirations are interpolated linearly in total variance at a fixed
+// log-moneyness.
+type Surface struct {
+	Spot          float64
+	Rate          float64
+	DividendYield float64
+	// Smiles are the expirations of the surface in ascending order.
+	Smiles []*Smile
+}
+
+// NewSurface builds a volatility surface from chain snapshots. The implied volatility returned by the server is used
+// if there is one, otherwise it is implied from MarketPrice. For each strike the out-of-the-money contract is
+// preferred since it is usually the more liquid one. Snapshots that can't be valued are skipped, and ErrInvalidInputs
+// is returned if none can.
+func NewSurface(snaps []models.OptionContractSnapshot, cfg Config, method SmileMethod) (*Surface, error) {
+	now := cfg.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	cfg.Now = now
+
+	s := &Surface{Rate: cfg.Rate, DividendYield: cfg.DividendYield}
+	for _, snap := range snaps {
+		if snap.UnderlyingAsset.Price > 0 {
+			s.Spot = snap.UnderlyingAsset.Price
+		}
+	}
+	if s.Spot <= 0 {
+		return nil, ErrInvalidInputs
+	}
+
+	type quote struct {
+		expiry float64
+		point  SmilePoint
+	}
+	byExpiration := make(map[civil.Date]map[float64][]quote)
+	for _, snap := range snaps {
+		c, err := ContractFromSnapshot(snap, cfg)
+		if err != nil {
+			continue
+		}
+
+		vol := snap.ImpliedVolatility
+		if vol <= 0 || cfg.Overwrite {
+			price, ok := MarketPrice(snap)
+			if !ok {
+				continue
+			}
+			if vol, err = ImpliedVolatility(c, price); err != nil {
+				continue
+			}
+		}
+
+		exp := snap.Details.ExpirationDate
+		if byExpiration[exp] == nil {
+			byExpiration[exp] = make(map[float64][]quote)
+		}
+		byExpiration[exp][c.Strike] = append(byExpiration[exp][c.Strike], quote{
+			expiry: c.Expiry,
+			point:  SmilePoint{Strike: c.Strike, Vol: vol, Type: c.Type},
+		})
+	}
+
+	for exp, strikes := range byExpiration {
+		var smile *Smile
+		for strike, quotes := range strikes {
+			if smile == nil {
+				t := quotes[0].expiry
+				smile = &Smile{Expiration: exp, Expiry: t, Forward: s.forward(t)}
+			}
+
+			otm := models.ContractCall
+			if strike < smile.Forward {
+				otm = models.ContractPut
+			}
+			pt := quotes[0].point
+			for _, q := range quotes {
+				if q.point.Type == otm {
+					pt = q.point
+				}
+			}
+			pt.Moneyness = math.Log(strike / smile.Forward)
+			smile.Points = append(smile.Points, pt)
+		}
+		sort.Slice(smile.Points, func(i, j int) bool { return smile.Points[i].Strike < smile.Points[j].Strike })
+
+		if method == SVI && len(smile.Points) >= minSVIPoints {
+			k, w := make([]float64, len(smile.Points)), make([]float64, len(smile.Points))
+			for i, pt := range smile.Points {
+				k[i], w[i] = pt.Moneyness, pt.Vol*pt.Vol*smile.Expiry
+			}
+			params := fitSVI(k, w)
+			smile.SVI = &params
+		}
+		s.Smiles = append(s.Smiles, smile)
+	}
+	if len(s.Smiles) == 0 {
+		return nil, ErrInvalidInputs
+	}
+	sort.Slice(s.Smiles, func(i, j int) bool { return s.Smiles[i].Expiry < s.Smiles[j].Expiry })
+
+	return s, nil
+}
+
+// TotalVariance returns the total implied variance of the smile at log-moneyness k.
+func (s *Smile) TotalVariance(k float64) float64 {
+	if s.SVI != nil {
+		return math.Max(s.SVI.TotalVariance(k), 0)
+	}
+
+	pts := s.Points
+	w := func(i int) float64 { return pts[i].Vol * pts[i].Vol * s.Expiry }
+	if k <= pts[0].Moneyness {
+		return w(0)
+	}
+	last := len(pts) - 1
+	if k >= pts[last].Moneyness {
+		return w(last)
+	}
+	i := sort.Search(len(pts), func(i int) bool { return pts[i].Moneyness >= k })
+	x := (k - pts[i-1].Moneyness) / (pts[i].Moneyness - pts[i-1].Moneyness)
+	return w(i-1) + x*(w(i)-w(i-1))
+}
+
+// Vol returns the implied volatility of the smile at a strike.
+func (s *Smile) Vol(strike float64) float64 {
+	return math.Sqrt(s.TotalVariance(math.Log(strike/s.Forward)) / s.Expiry)
+}
+
+// Vol returns the implied volatility at a strike and a time to expiration in years. Total variance is interpolated
+// linearly between expirations, and outside of the listed expirations the volatility of the nearest one is held
+// constant.
+func (s *Surface) Vol(strike, expiry float64) float64 {
+	if expiry <= 0 || strike <= 0 {
+		return 0
+	}
+	return math.Sqrt(s.totalVariance(math.Log(strike/s.forward(expiry)), expiry) / expiry)
+}
+
+// TermStructure returns the at-the-money forward implied volatility of every expiration.
+func (s *Surface) TermStructure() []TermPoint {
+	out := make([]TermPoint, 0, len(s.Smiles))
+	for _, smile := range s.Smiles {
+		out = append(out, TermPoint{
+			Expiration: smile.Expiration,
+			Expiry:     smile.Expiry,
+			Forward:    smile.Forward,
+			ATMVol:     math.Sqrt(smile.TotalVariance(0) / smile.Expiry),
+		})
+	}
+	return out
+}
+
+// Skew returns the risk reversal and butterfly of every expiration for calls and puts with the given absolute delta,
+// e.g. 0.25 for the 25-delta risk reversal. Deltas are spot deltas computed with each strike's own volatility, as in
+// ComputeGreeks. Expirations where no strike has the requested delta are skipped.
+func (s *Surface) Skew(delta float64) []Skew {
+	var out []Skew
+	for _, smile := range s.Smiles {
+		callK, ok := s.deltaStrike(smile, models.ContractCall, delta)
+		if !ok {
+			continue
+		}
+		putK, ok := s.deltaStrike(smile, models.ContractPut, delta)
+		if !ok {
+			continue
+		}
+
+		atm := math.Sqrt(smile.TotalVariance(0) / smile.Expiry)
+		callVol, putVol := smile.Vol(callK), smile.Vol(putK)
+		out = append(out, Skew{
+			Expiration:   smile.Expiration,
+			Expiry:       smile.Expiry,
+			ATMVol:       atm,
+			CallStrike:   callK,
+			CallVol:      callVol,
+			PutStrike:    putK,
+			PutVol:       putVol,
+			RiskReversal: callVol - putVol,
+			Butterfly:    (callVol+putVol)/2 - atm,
+		})
+	}
+	return out
+}
+
+// Arbitrage checks the surface for static arbitrage at the quoted strikes. Calendar arbitrage compares the total
+// variance of consecutive expirations at the moneyness of every quote of either, and butterfly arbitrage checks that
+// call prices are convex in strike across the quotes of each expiration.
+func (s *Surface) Arbitrage() []Violation {
+	const tolerance = 1e-9
+
+	var out []Violation
+	for i, smile := range s.Smiles {
+		// butterflies
+		pts := smile.Points
+		for j := 1; j+1 < len(pts); j++ {
+			k1, k2, k3 := pts[j-1].Strike, pts[j].Strike, pts[j+1].Strike
+			c1, c2, c3 := smile.forwardCall(k1), smile.forwardCall(k2), smile.forwardCall(k3)
+			x := (k3 - k2) / (k3 - k1)
+			if excess := (c2 - x*c1 - (1-x)*c3) / smile.Forward; excess > tolerance {
+				out = append(out, Violation{Kind: ButterflyArbitrage, Expiration: smile.Expiration, Strike: k2, Amount: excess})
+			}
+		}
+
+		// calendars
+		if i == 0 {
+			continue
+		}
+		prev := s.Smiles[i-1]
+		moneyness := make([]float64, 0, len(prev.Points)+len(pts))
+		for _, pt := range prev.Points {
+			moneyness = append(moneyness, pt.Moneyness)
+		}
+		for _, pt := range pts {
+			moneyness = append(moneyness, pt.Moneyness)
+		}
+		sort.Float64s(moneyness)
+		for j, k := range moneyness {
+			if j > 0 && k == moneyness[j-1] {
+				continue
+			}
+			if drop := prev.TotalVariance(k) - smile.TotalVariance(k); drop > tolerance {
+				out = append(out, Violation{Kind: CalendarArbitrage, Expiration: smile.Expiration, Strike: smile.Forward * math.Exp(k), Amount: drop})
+			}
+		}
+	}
+	return out
+}
+
+func (s *Surface) forward(expiry float64) float64 {
+	return s.Spot * math.Exp((s.Rate-s.DividendYield)*expiry)
+}
+
+func (s *Surface) totalVariance(k, expiry float64) float64 {
+	first, last := s.Smiles[0], s.Smiles[len(s.Smiles)-1]
+	if expiry <= first.Expiry {
+		return first.TotalVariance(k) * expiry / first.Expiry
+	}
+	if expiry >= last.Expiry {
+		return last.TotalVariance(k) * expiry / last.Expiry
+	}
+
+	i := sort.Search(len(s.Smiles), func(i int) bool { return s.Smiles[i].Expiry >= expiry })
+	lo, hi := s.Smiles[i-1], s.Smiles[i]
+	x := (expiry - lo.Expiry) / (hi.Expiry - lo.Expiry)
+	return lo.TotalVariance(k) + x*(hi.TotalVariance(k)-lo.TotalVariance(k))
+}
+
+// deltaStrike finds the strike of an expiration whose spot delta has the given absolute value by bisection on
+// log-moneyness. Call deltas decrease and put deltas increase in absolute value with the strike.
+func (s *Surface) deltaStrike(smile *Smile, typ models.ContractType, delta float64) (float64, bool) {
+	qf := math.Exp(-s.DividendYield * smile.Expiry)
+	absDelta := func(k float64) float64 {
+		w := smile.TotalVariance(k)
+		if w <= 0 {
+			return math.NaN()
+		}
+		d1 := (-k + w/2) / math.Sqrt(w)
+		if typ == models.ContractCall {
+			return qf * normCDF(d1)
+		}
+		return qf * normCDF(-d1)
+	}
+	// the difference from the target increases with k for calls and decreases for puts
+	f := func(k float64) float64 {
+		if typ == models.ContractCall {
+			return delta - absDelta(k)
+		}
+		return absDelta(k) - delta
+	}
+
+	width := 10 * math.Sqrt(math.Max(smile.TotalVariance(0), 1e-8))
+	lo, hi := -width, width
+	flo, fhi := f(lo), f(hi)
+	if math.IsNaN(flo) || math.IsNaN(fhi) || flo > 0 || fhi < 0 {
+		return 0, false
+	}
+	for i := 0; i < 100 && hi-lo > 1e-10; i++ {
+		mid := (lo + hi) / 2
+		fm := f(mid)
+		if math.IsNaN(fm) {
+			return 0, false
+		}
+		if fm < 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return smile.Forward * math.Exp((lo+hi)/2), true
+}
+
+// forwardCall returns the undiscounted Black price of a call at a strike using the smile's volatility.
+func (s *Smile) forwardCall(strike float64) float64 {
+	w := s.TotalVariance(math.Log(strike / s.Forward))
+	if w <= 0 {
+		return math.Max(s.Forward-strike, 0)
+	}
+	d1 := (math.Log(s.Forward/strike) + w/2) / math.Sqrt(w)
+	return s.Forward*normCDF(d1) - strike*normCDF(d1-math.Sqrt(w))
+}
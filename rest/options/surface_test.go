@@ -0,0 +1,116 @@
+package options_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/options"
+	"github.com/stretchr/testify/assert"
+)
+
+// smileSnapshots returns out-of-the-money quotes whose implied volatility follows vol at the given strikes.
+func smileSnapshots(exp civil.Date, spot float64, strikes []float64, vol func(k float64) float64) []models.OptionContractSnapshot {
+	var snaps []models.OptionContractSnapshot
+	for _, k := range strikes {
+		typ := "call"
+		if k < spot {
+			typ = "put"
+		}
+		snap := snapshot(exp, typ, k, 0, 0, spot)
+		snap.Details.ExerciseStyle = "european"
+		snap.ImpliedVolatility = vol(k)
+		snaps = append(snaps, snap)
+	}
+	return snaps
+}
+
+func TestSurface(t *testing.T) {
+	now := time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)
+	cfg := options.Config{Now: now, Location: time.UTC}
+	near, far := civil.DateOf(now).AddDays(73), civil.DateOf(now).AddDays(365)
+	strikes := []float64{70, 80, 90, 95, 100, 105, 110, 120, 130}
+
+	// a downward sloping smile with total variance generated by known SVI parameters
+	nearSVI := options.SVIParams{A: 0.004, B: 0.04, Rho: -0.5, M: 0.02, Sigma: 0.2}
+	farSVI := options.SVIParams{A: 0.02, B: 0.15, Rho: -0.4, M: 0.05, Sigma: 0.3}
+	svi := func(p options.SVIParams, expiry float64) func(k float64) float64 {
+		return func(k float64) float64 { return math.Sqrt(p.TotalVariance(math.Log(k/100)) / expiry) }
+	}
+	snaps := append(smileSnapshots(near, 100, strikes, svi(nearSVI, 0.2)), smileSnapshots(far, 100, strikes, svi(farSVI, 1))...)
+
+	s, err := options.NewSurface(snaps, cfg, options.SVI)
+	assert.Nil(t, err)
+	assert.Len(t, s.Smiles, 2)
+	assert.Equal(t, near, s.Smiles[0].Expiration)
+	assert.InDelta(t, 0.2, s.Smiles[0].Expiry, 1e-9)
+
+	// the fit reproduces the quotes and interpolates between them
+	for _, k := range []float64{75, 100, 125} {
+		assert.InDelta(t, svi(nearSVI, 0.2)(k), s.Vol(k, 0.2), 1e-3)
+		assert.InDelta(t, svi(farSVI, 1)(k), s.Vol(k, 1), 1e-3)
+	}
+	mid := s.Vol(100, 0.6)
+	expected := math.Sqrt((nearSVI.TotalVariance(0) + (farSVI.TotalVariance(0)-nearSVI.TotalVariance(0))/2) / 0.6)
+	assert.InDelta(t, expected, mid, 1e-3)
+
+	ts := s.TermStructure()
+	assert.Len(t, ts, 2)
+	assert.InDelta(t, math.Sqrt(farSVI.TotalVariance(0)), ts[1].ATMVol, 1e-3)
+
+	skew := s.Skew(0.25)
+	assert.Len(t, skew, 2)
+	assert.Less(t, skew[0].RiskReversal, 0.0)
+	assert.Greater(t, skew[0].Butterfly, 0.0)
+	assert.Greater(t, skew[0].CallStrike, 100.0)
+	assert.Less(t, skew[0].PutStrike, 100.0)
+
+	assert.Empty(t, s.Arbitrage())
+
+	// a far expiration with less total variance than the near one is a calendar arbitrage
+	flat := smileSnapshots(far, 100, strikes, func(float64) float64 { return 0.05 })
+	s, err = options.NewSurface(append(smileSnapshots(near, 100, strikes, svi(nearSVI, 0.2)), flat...), cfg, options.SVI)
+	assert.Nil(t, err)
+	violations := s.Arbitrage()
+	assert.NotEmpty(t, violations)
+	assert.Equal(t, options.CalendarArbitrage, violations[0].Kind)
+	assert.Equal(t, far, violations[0].Expiration)
+}
+
+func TestSurfaceLinear(t *testing.T) {
+	now := time.Date(2024, 1, 1, 16, 0, 0, 0, time.UTC)
+	cfg := options.Config{Now: now, Location: time.UTC}
+	exp := civil.DateOf(now).AddDays(365)
+
+	// a volatility spike at a single strike makes the butterfly around it negative
+	snaps := smileSnapshots(exp, 100, []float64{90, 100, 110}, func(k float64) float64 {
+		if k == 100 {
+			return 0.6
+		}
+		return 0.2
+	})
+	s, err := options.NewSurface(snaps, cfg, options.SVI)
+	assert.Nil(t, err)
+	assert.Nil(t, s.Smiles[0].SVI) // too few quotes to fit
+	assert.InDelta(t, 0.45, s.Smiles[0].Vol(95), 0.01)
+
+	violations := s.Arbitrage()
+	assert.Len(t, violations, 1)
+	assert.Equal(t, options.ButterflyArbitrage, violations[0].Kind)
+	assert.Equal(t, 100.0, violations[0].Strike)
+
+	// volatilities are implied from prices when the server didn't return them
+	c := options.Contract{Type: models.ContractCall, Strike: 110, Expiry: 1, Spot: 100}
+	price, err := options.Price(c, 0.3)
+	assert.Nil(t, err)
+	snap := snapshot(exp, "call", 110, price, price, 100)
+	snap.Details.ExerciseStyle = "european"
+	s, err = options.NewSurface([]models.OptionContractSnapshot{snap}, cfg, options.Linear)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.3, s.Vol(110, 1), 1e-6)
+
+	_, err = options.NewSurface(nil, cfg, options.Linear)
+	assert.ErrorIs(t, err, options.ErrInvalidInputs)
+}
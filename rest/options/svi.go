@@ -0,0 +1,237 @@
+package options
+
+import "math"
+
+// SVIParams are the parameters of Gatheral's raw stochastic volatility inspired (SVI) parameterization of total
+// implied variance w(k) = A + B*(Rho*(k-M) + sqrt((k-M)^2 + Sigma^2)), where k is the log of strike over forward.
+type SVIParams struct {
+	A     float64 `json:"a"`
+	B     float64 `json:"b"`
+	Rho   float64 `json:"rho"`
+	M     float64 `json:"m"`
+	Sigma float64 `json:"sigma"`
+}
+
+// TotalVariance returns the total implied variance at log-moneyness k.
+func (p SVIParams) TotalVariance(k float64) float64 {
+	y := k - p.M
+	return p.A + p.B*(p.Rho*y+math.Sqrt(y*y+p.Sigma*p.Sigma))
+}
+
+// fitSVI fits raw SVI parameters to total variances w at log-moneyness k. It uses the quasi-explicit method of
+// De Marco and Martini: for a fixed M and Sigma the remaining parameters solve a constrained linear least squares
+// problem, so only the two-dimensional outer problem needs a numerical search.
+func fitSVI(k, w []float64) SVIParams {
+	kmin, kmax := k[0], k[0]
+	for _, x := range k {
+		kmin, kmax = math.Min(kmin, x), math.Max(kmax, x)
+	}
+	width := math.Max(kmax-kmin, 1e-4)
+
+	inner := func(m, sigma float64) (SVIParams, float64) {
+		return sviInner(k, w, m, sigma)
+	}
+	cost := func(x [2]float64) float64 {
+		if x[1] < 1e-4 {
+			return math.Inf(1)
+		}
+		_, sse := inner(x[0], x[1])
+		return sse
+	}
+
+	// a coarse grid search avoids the local minima of the outer problem before refining
+	best, bestCost := [2]float64{}, math.Inf(1)
+	for i := 0; i <= 20; i++ {
+		m := kmin - width/2 + 2*width*float64(i)/20
+		for j := 0; j <= 15; j++ {
+			sigma := 1e-3 * math.Pow(1000, float64(j)/15)
+			if c := cost([2]float64{m, sigma}); c < bestCost {
+				best, bestCost = [2]float64{m, sigma}, c
+			}
+		}
+	}
+	best = nelderMead(cost, best, [2]float64{width / 10, best[1] / 2})
+
+	p, _ := inner(best[0], best[1])
+	return p
+}
+
+// sviInner solves for A, B, and Rho given M and Sigma and returns the fit and its sum of squared errors. The linear
+// problem is posed in terms of A, C = B*Rho, and D = B subject to D >= 0 and |C| <= D, and is solved on the boundary
+// of that region whenever the unconstrained solution falls outside of it.
+func sviInner(k, w []float64, m, sigma float64) (SVIParams, float64) {
+	n := len(k)
+	y, z := make([]float64, n), make([]float64, n)
+	for i := range k {
+		y[i] = k[i] - m
+		z[i] = math.Sqrt(y[i]*y[i] + sigma*sigma)
+	}
+
+	sse := func(a, c, d float64) float64 {
+		var s float64
+		for i := range k {
+			e := a + c*y[i] + d*z[i] - w[i]
+			s += e * e
+		}
+		// the minimum total variance must not be negative
+		if a+sigma*math.Sqrt(math.Max(d*d-c*c, 0)) < 0 {
+			s += 1e6
+		}
+		return s
+	}
+
+	type candidate struct{ a, c, d float64 }
+	var cands []candidate
+
+	if a, c, d, ok := leastSquares3(y, z, w); ok && d >= 0 && math.Abs(c) <= d {
+		cands = append(cands, candidate{a, c, d})
+	} else {
+		// c = d and c = -d reduce the problem to a regression of w on y+z and z-y
+		for _, sign := range []float64{1, -1} {
+			x := make([]float64, n)
+			for i := range x {
+				x[i] = z[i] + sign*y[i]
+			}
+			if a, d, ok := leastSquares2(x, w); ok && d >= 0 {
+				cands = append(cands, candidate{a, sign * d, d})
+			}
+		}
+		var mean float64
+		for _, v := range w {
+			mean += v / float64(n)
+		}
+		cands = append(cands, candidate{mean, 0, 0})
+	}
+
+	best, bestSSE := candidate{}, math.Inf(1)
+	for _, c := range cands {
+		if s := sse(c.a, c.c, c.d); s < bestSSE {
+			best, bestSSE = c, s
+		}
+	}
+
+	p := SVIParams{A: best.a, B: best.d, M: m, Sigma: sigma}
+	if best.d > 0 {
+		p.Rho = best.c / best.d
+	}
+	return p, bestSSE
+}
+
+// leastSquares3 regresses w on an intercept, y, and z.
+func leastSquares3(y, z, w []float64) (a, c, d float64, ok bool) {
+	var m [3][4]float64
+	for i := range w {
+		row := [3]float64{1, y[i], z[i]}
+		for r := 0; r < 3; r++ {
+			for col := 0; col < 3; col++ {
+				m[r][col] += row[r] * row[col]
+			}
+			m[r][3] += row[r] * w[i]
+		}
+	}
+
+	// Gaussian elimination with partial pivoting
+	for col := 0; col < 3; col++ {
+		pivot := col
+		for r := col + 1; r < 3; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-14 {
+			return 0, 0, 0, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for r := col + 1; r < 3; r++ {
+			f := m[r][col] / m[col][col]
+			for j := col; j < 4; j++ {
+				m[r][j] -= f * m[col][j]
+			}
+		}
+	}
+	var x [3]float64
+	for r := 2; r >= 0; r-- {
+		s := m[r][3]
+		for j := r + 1; j < 3; j++ {
+			s -= m[r][j] * x[j]
+		}
+		x[r] = s / m[r][r]
+	}
+	return x[0], x[1], x[2], true
+}
+
+// leastSquares2 regresses w on an intercept and x.
+func leastSquares2(x, w []float64) (a, b float64, ok bool) {
+	n := float64(len(x))
+	var sx, sw, sxx, sxw float64
+	for i := range x {
+		sx += x[i]
+		sw += w[i]
+		sxx += x[i] * x[i]
+		sxw += x[i] * w[i]
+	}
+	det := n*sxx - sx*sx
+	if math.Abs(det) < 1e-14 {
+		return 0, 0, false
+	}
+	b = (n*sxw - sx*sw) / det
+	return (sw - b*sx) / n, b, true
+}
+
+// nelderMead minimizes f in two dimensions starting from x0 with an initial simplex of the given step sizes.
+func nelderMead(f func([2]float64) float64, x0, step [2]float64) [2]float64 {
+	pts := [3][2]float64{x0, {x0[0] + step[0], x0[1]}, {x0[0], x0[1] + step[1]}}
+	vals := [3]float64{f(pts[0]), f(pts[1]), f(pts[2])}
+
+	lerp := func(a, b [2]float64, t float64) [2]float64 {
+		return [2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}
+	}
+
+	for n := 0; n < 500; n++ {
+		// order the simplex from best to worst
+		for i := 0; i < 3; i++ {
+			for j := i + 1; j < 3; j++ {
+				if vals[j] < vals[i] {
+					pts[i], pts[j] = pts[j], pts[i]
+					vals[i], vals[j] = vals[j], vals[i]
+				}
+			}
+		}
+		if math.Abs(vals[2]-vals[0]) < 1e-16 {
+			break
+		}
+
+		centroid := lerp(pts[0], pts[1], 0.5)
+		reflected := lerp(centroid, pts[2], -1)
+		fr := f(reflected)
+		switch {
+		case fr < vals[0]:
+			expanded := lerp(centroid, pts[2], -2)
+			if fe := f(expanded); fe < fr {
+				pts[2], vals[2] = expanded, fe
+			} else {
+				pts[2], vals[2] = reflected, fr
+			}
+		case fr < vals[1]:
+			pts[2], vals[2] = reflected, fr
+		default:
+			contracted := lerp(centroid, pts[2], 0.5)
+			if fc := f(contracted); fc < vals[2] {
+				pts[2], vals[2] = contracted, fc
+			} else {
+				for i := 1; i < 3; i++ {
+					pts[i] = lerp(pts[0], pts[i], 0.5)
+					vals[i] = f(pts[i])
+				}
+			}
+		}
+	}
+
+	best := 0
+	for i := 1; i < 3; i++ {
+		if vals[i] < vals[best] {
+			best = i
+		}
+	}
+	return pts[best]
+}
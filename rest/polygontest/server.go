@@ -0,0 +1,225 @@
+// Package polygontest provides a local fake of the Polygon REST API that serves responses from a fixture directory.
+//
+// Every request is answered with the fixture file that matches its path and query, so any endpoint of the client
+// (aggregates, trades, snapshots, financials, and so on) can be served without registering handlers per test. The
+// fixture of a request is named after its path and query, where the query excludes the API key and is sorted by
+// parameter name:
+//
+//	<dir>/<path>@<query>.json
+//	<dir>/<path>.json
+//
+// The second form is for requests without a query. For example, a ListTrades call for AAPL with a limit of 10 is
+// served from "v3/trades/AAPL@limit=10.json". With MatchPath set, requests whose query has no fixture fall back to the
+// fixture of their path, so "v3/trades/AAPL.json" serves every query for AAPL trades. Links to the next page of results in a fixture point at the real API and are
+// rewritten to point at the fake server, so paginated endpoints work as long as each page has a fixture.
+//
+// In record mode, requests without a fixture are forwarded to the real API once and the response is saved as a
+// fixture with the API key scrubbed:
+//
+//	s := polygontest.NewServer(polygontest.Config{Dir: "testdata", Record: os.Getenv("RECORD") != ""})
+//	defer s.Close()
+//	c := s.Client(os.Getenv("POLYGON_API_KEY"))
+package polygontest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	polygon "github.com/polygon-io/client-go/rest"
+	"github.com/polygon-io/client-go/rest/client"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+// Config is a set of fake server options.
+type Config struct {
+	// Dir is the fixture directory.
+	Dir string
+
+	// Record forwards requests that don't have a fixture to Upstream and saves successful responses as fixtures.
+	Record bool
+
+	// Upstream is the API that requests are forwarded to in record mode. Omitting this uses the real API.
+	Upstream string
+
+	// MatchPath serves requests whose query has no fixture from the fixture of their path, whatever the query. It's
+	// ignored in record mode so that every new query is recorded.
+	MatchPath bool
+}
+
+// Server is a fake Polygon REST API backed by a fixture directory.
+type Server struct {
+	// URL is the base URL of the server.
+	URL string
+
+	dir       string
+	record    bool
+	matchPath bool
+	upstream  string
+	srv       *httptest.Server
+
+	mtx      sync.Mutex
+	requests []string
+}
+
+// NewServer starts a fake server.
+func NewServer(config Config) *Server {
+	s := &Server{
+		dir:       config.Dir,
+		record:    config.Record,
+		matchPath: config.MatchPath && !config.Record,
+		upstream:  strings.TrimSuffix(config.Upstream, "/"),
+	}
+	if s.upstream == "" {
+		s.upstream = client.APIURL
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	s.URL = s.srv.URL
+	return s
+}
+
+// Client returns a REST client that sends its requests to the fake server. The API key only matters in record mode,
+// where it's used to call the real API.
+func (s *Server) Client(apiKey string) *polygon.Client {
+	c := polygon.New(apiKey)
+	c.HTTP.SetBaseURL(s.URL)
+	c.HTTP.SetRetryCount(0)
+	return c
+}
+
+// Requests returns the path and query of every request the server received, in order.
+func (s *Server) Requests() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// FixturePath returns the fixture file that a request for the given path and query is served from.
+func FixturePath(dir, path string, query url.Values) string {
+	query = canonicalQuery(query)
+	name := filepath.Join(dir, filepath.FromSlash(strings.Trim(path, "/")))
+	if len(query) == 0 {
+		return name + ".json"
+	}
+	return name + "@" + url.PathEscape(query.Encode()) + ".json"
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	query := canonicalQuery(r.URL.Query())
+	s.mtx.Lock()
+	s.requests = append(s.requests, r.URL.Path+queryString(query))
+	s.mtx.Unlock()
+
+	exact := FixturePath(s.dir, r.URL.Path, query)
+	body, err := os.ReadFile(exact)
+	if os.IsNotExist(err) && s.matchPath {
+		body, err = os.ReadFile(FixturePath(s.dir, r.URL.Path, nil))
+	}
+	if os.IsNotExist(err) && s.record {
+		body, err = s.fetch(r, exact)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if os.IsNotExist(err) {
+			status = http.StatusNotFound
+			err = fmt.Errorf("no fixture for %v", exact)
+		}
+		s.error(w, status, err)
+		return
+	}
+
+	// point pagination links at this server
+	body = bytes.ReplaceAll(body, []byte(s.upstream), []byte(s.URL))
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// fetch forwards a request to the upstream API and saves a successful response as a fixture.
+func (s *Server) fetch(r *http.Request, fixture string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, s.upstream+r.URL.RequestURI(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call upstream: %w", err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upstream response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("upstream returned %v: %s", res.StatusCode, body)
+	}
+
+	body = scrub(body, apiKey(r))
+	if err := os.MkdirAll(filepath.Dir(fixture), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(fixture, body, 0o644); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) error(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(models.ErrorResponse{
+		BaseResponse: models.BaseResponse{Status: "ERROR", RequestID: "polygontest", ErrorMessage: err.Error()},
+	})
+}
+
+// canonicalQuery returns the query without the API key. Encoding the result sorts it by key.
+func canonicalQuery(query url.Values) url.Values {
+	out := make(url.Values, len(query))
+	for k, v := range query {
+		if k != "apiKey" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func queryString(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+// apiKey returns the API key of a request, which is sent as a bearer token or as a query parameter.
+func apiKey(r *http.Request) string {
+	if key := r.URL.Query().Get("apiKey"); key != "" {
+		return key
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// scrub removes every occurrence of the API key from a response body.
+func scrub(body []byte, key string) []byte {
+	if key == "" {
+		return body
+	}
+	return bytes.ReplaceAll(body, []byte(key), []byte("REDACTED"))
+}
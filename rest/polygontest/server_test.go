@@ -0,0 +1,106 @@
+package polygontest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+	c := s.Client("API_KEY")
+
+	// the next page is served from the fixture matching its cursor
+	iter := c.ListTrades(context.Background(), models.ListTradesParams{Ticker: "AAPL"}.WithLimit(1))
+	var ids []string
+	for iter.Next() {
+		ids = append(ids, iter.Item().ID)
+	}
+	assert.Nil(t, iter.Err())
+	assert.Equal(t, []string{"1", "2"}, ids)
+	assert.Equal(t, []string{
+		"/v3/trades/AAPL?limit=1",
+		"/v3/trades/AAPL?cursor=YWN0aXZlPXRydWUmZGF0ZT0yMDIxLTA3LTIy",
+	}, s.Requests())
+
+	// requests without a fixture fail like a missing resource
+	_, err := c.GetLastTrade(context.Background(), &models.GetLastTradeParams{Ticker: "AAPL"})
+	assert.NotNil(t, err)
+	errRes, ok := err.(*models.ErrorResponse)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, errRes.StatusCode)
+
+	// a fixture recorded for another query isn't used
+	iter = c.ListTrades(context.Background(), models.ListTradesParams{Ticker: "AAPL"}.WithLimit(2))
+	assert.False(t, iter.Next())
+	assert.NotNil(t, iter.Err())
+}
+
+func TestServerMatchPath(t *testing.T) {
+	dir := t.TempDir()
+	fixture := polygontest.FixturePath(dir, "/v2/last/trade/AAPL", nil)
+	assert.Nil(t, os.MkdirAll(filepath.Dir(fixture), 0o755))
+	assert.Nil(t, os.WriteFile(fixture, []byte(`{"status":"OK","results":{"T":"AAPL","p":171.55}}`), 0o644))
+
+	// requests with any query are served from the fixture of their path
+	s := polygontest.NewServer(polygontest.Config{Dir: dir, MatchPath: true})
+	defer s.Close()
+	res, err := s.Client("API_KEY").GetLastTrade(context.Background(), &models.GetLastTradeParams{Ticker: "AAPL"},
+		models.QueryParam("extra", "1"))
+	assert.Nil(t, err)
+	assert.Equal(t, 171.55, res.Results.Price)
+
+	// in record mode, the query is recorded instead
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"OK","results":{"T":"AAPL","p":172}}`))
+	}))
+	defer upstream.Close()
+	rs := polygontest.NewServer(polygontest.Config{Dir: dir, MatchPath: true, Record: true, Upstream: upstream.URL})
+	defer rs.Close()
+	res, err = rs.Client("API_KEY").GetLastTrade(context.Background(), &models.GetLastTradeParams{Ticker: "AAPL"},
+		models.QueryParam("extra", "1"))
+	assert.Nil(t, err)
+	assert.Equal(t, 172.0, res.Results.Price)
+	_, err = os.Stat(polygontest.FixturePath(dir, "/v2/last/trade/AAPL", url.Values{"extra": {"1"}}))
+	assert.Nil(t, err)
+}
+
+func TestServerRecord(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer SECRET", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"OK","request_id":"req","results":{"T":"AAPL","p":171.55,"i":"SECRET-1"}}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	s := polygontest.NewServer(polygontest.Config{Dir: dir, Record: true, Upstream: upstream.URL})
+	res, err := s.Client("SECRET").GetLastTrade(context.Background(), &models.GetLastTradeParams{Ticker: "AAPL"})
+	assert.Nil(t, err)
+	assert.Equal(t, 171.55, res.Results.Price)
+	s.Close()
+
+	// the fixture is saved with the API key scrubbed and replays offline
+	fixture := polygontest.FixturePath(dir, "/v2/last/trade/AAPL", url.Values{})
+	assert.Equal(t, filepath.Join(dir, "v2", "last", "trade", "AAPL.json"), fixture)
+	data, err := os.ReadFile(fixture)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "SECRET")
+
+	upstream.Close()
+	s = polygontest.NewServer(polygontest.Config{Dir: dir})
+	defer s.Close()
+	res, err = s.Client("API_KEY").GetLastTrade(context.Background(), &models.GetLastTradeParams{Ticker: "AAPL"})
+	assert.Nil(t, err)
+	assert.Equal(t, "REDACTED-1", res.Results.ID)
+}
@@ -0,0 +1,341 @@
+// Package universe builds a point-in-time security master.
+//
+// Tickers change and get reused, so they can't identify a security across dates. A Master keys securities by their
+// composite FIGI, which stays the same through renames, and keeps the history of their tickers from
+// VXClient.GetTickerEvents. Since it also holds delisted securities, it returns universes without survivorship bias
+// for any historical date:
+//
+//	m, err := universe.Load(ctx, c, c.VX, models.ListTickersParams{}.WithMarket(models.AssetStocks).WithType("CS"))
+//	if err != nil {
+//		return err
+//	}
+//	sec, _ := m.Resolve("FB", civil.Date{Year: 2021, Month: 6, Day: 1})
+//	ticker, _ := sec.TickerOn(civil.Date{Year: 2023, Month: 1, Day: 3}) // META
+//	members := m.Universe(civil.Date{Year: 2008, Month: 9, Day: 15})     // includes LEH
+package universe
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/iter"
+	"github.com/polygon-io/client-go/rest/models"
+)
+
+const (
+	// eventTickerChange is the type of ticker change events.
+	eventTickerChange = "ticker_change"
+
+	// eventWorkers is the number of securities whose ticker changes are requested at the same time.
+	eventWorkers = 4
+)
+
+// Lister lists tickers. The Client of the rest package implements it.
+type Lister interface {
+	ListTickers(ctx context.Context, params *models.ListTickersParams, options ...models.RequestOption) *iter.Iter[models.Ticker]
+}
+
+// EventGetter gets ticker events. The VXClient of the rest package implements it.
+type EventGetter interface {
+	GetTickerEvents(ctx context.Context, params *models.GetTickerEventsParams, options ...models.RequestOption) (*models.GetTickerEventsResponse, error)
+}
+
+// Symbol is a ticker that a security traded under for a range of dates.
+type Symbol struct {
+	Ticker string
+
+	// From is the first date of the ticker, which is zero if it's unknown.
+	From civil.Date
+
+	// To is the first date after the ticker, which is zero if the security still trades under it.
+	To civil.Date
+}
+
+// Security is a security that keeps its identity through ticker changes.
+type Security struct {
+	// ID is the composite FIGI of the security, or its share class FIGI if it has none. Securities without either,
+	// which are mostly long delisted, are identified by their ticker and delisting date.
+	ID string
+
+	CompositeFIGI  string
+	ShareClassFIGI string
+
+	// Listed is the list date of the most recent reference data, which is zero if it's unknown. ListTickers leaves it
+	// out for most tickers, while GetTickerDetails usually has it. ListedFrom falls back to the first known ticker.
+	Listed civil.Date
+
+	// Delisted is the date the security was delisted, which is zero if it's still active.
+	Delisted civil.Date
+
+	// Symbols are the tickers of the security in date order.
+	Symbols []Symbol
+
+	// Reference is the most recent reference data of the security.
+	Reference models.Ticker
+
+	// changed is whether the symbols come from ticker changes
+	changed bool
+}
+
+// ListedOn reports whether the security was listed on a date. Listing dates are missing from lists of tickers, so a
+// security without one is only known to be listed from its first ticker change. A security without either is assumed
+// to have been listed on any date before it was delisted.
+func (s *Security) ListedOn(d civil.Date) bool {
+	return !d.Before(s.ListedFrom()) && (s.Delisted.IsZero() || d.Before(s.Delisted))
+}
+
+// ListedFrom returns the listing date of the security, or the date of its first ticker if that's unknown. It's zero if
+// both are unknown.
+func (s *Security) ListedFrom() civil.Date {
+	if s.Listed.IsZero() && len(s.Symbols) > 0 {
+		return s.Symbols[0].From
+	}
+	return s.Listed
+}
+
+// TickerOn returns the ticker of the security on a date. Dates before the first known ticker resolve to it, and dates
+// after the security was delisted resolve to its last ticker.
+func (s *Security) TickerOn(d civil.Date) (string, bool) {
+	if len(s.Symbols) == 0 {
+		return "", false
+	}
+	for i := len(s.Symbols) - 1; i >= 0; i-- {
+		if !d.Before(s.Symbols[i].From) {
+			return s.Symbols[i].Ticker, true
+		}
+	}
+	return s.Symbols[0].Ticker, true
+}
+
+// Member is a security in the universe of a date, along with its ticker on that date.
+type Member struct {
+	Ticker string
+	*Security
+}
+
+// Master is a point-in-time security master. It's safe for concurrent use once built.
+type Master struct {
+	securities map[string]*Security
+}
+
+// New creates an empty master.
+func New() *Master {
+	return &Master{securities: make(map[string]*Security)}
+}
+
+// Load builds a master from the active and delisted tickers that match a set of parameters, and the ticker changes of
+// every security that has a composite FIGI. The active filter of the parameters is ignored and the parameters aren't
+// modified. Events can be nil to skip ticker changes, which take a request per security, made a few at a time.
+func Load(ctx context.Context, tickers Lister, events EventGetter, params *models.ListTickersParams) (*Master, error) {
+	var p models.ListTickersParams
+	if params != nil {
+		p = *params
+	}
+
+	m := New()
+	for _, active := range []bool{true, false} {
+		it := tickers.ListTickers(ctx, p.WithActive(active))
+		for it.Next() {
+			m.Add(it.Item())
+		}
+		if it.Err() != nil {
+			return nil, it.Err()
+		}
+	}
+	if events == nil {
+		return m, nil
+	}
+	if err := m.loadEvents(ctx, events); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadEvents gets the ticker changes of every security that has a composite FIGI. The first error cancels the
+// remaining requests.
+func (m *Master) loadEvents(ctx context.Context, events EventGetter) error {
+	var secs []*Security
+	for _, sec := range m.Securities() {
+		if sec.CompositeFIGI != "" {
+			secs = append(secs, sec)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		once     sync.Once
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	results := make([][]models.TickerEvent, len(secs))
+	queue := make(chan int)
+	for w := 0; w < min(eventWorkers, len(secs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				params := models.GetTickerEventsParams{ID: secs[i].CompositeFIGI}.WithTypes(eventTickerChange)
+				res, err := events.GetTickerEvents(ctx, params)
+				if err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					continue
+				}
+				for _, r := range res.Results {
+					results[i] = append(results[i], r.Events...)
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range secs {
+		select {
+		case queue <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// the master isn't safe for concurrent use while it's built
+	for i, sec := range secs {
+		m.AddEvents(sec.ID, results[i])
+	}
+	return nil
+}
+
+// Add adds the reference data of a ticker, e.g. from a call to ListTickers for a point in time. Records of the same
+// security are merged, keeping the most recently updated one as its reference data.
+func (m *Master) Add(t models.Ticker) {
+	id := idOf(t)
+
+	sec, ok := m.securities[id]
+	if !ok {
+		sec = &Security{ID: id}
+		m.securities[id] = sec
+	} else if time.Time(t.LastUpdatedUTC).Before(time.Time(sec.Reference.LastUpdatedUTC)) {
+		return
+	}
+
+	sec.CompositeFIGI = t.CompositeFIGI
+	sec.ShareClassFIGI = t.ShareClassFIGI
+	sec.Listed = t.ListDate
+	sec.Delisted = civil.Date{}
+	if !t.Active && !time.Time(t.DelistedUTC).IsZero() {
+		sec.Delisted = civil.DateOf(time.Time(t.DelistedUTC).UTC())
+	}
+	sec.Reference = t
+
+	// without ticker changes, the security is assumed to have traded under its latest ticker since it was listed
+	if !sec.changed {
+		sec.Symbols = []Symbol{{Ticker: t.Ticker, From: sec.Listed, To: sec.Delisted}}
+	} else {
+		sec.Symbols[len(sec.Symbols)-1].To = sec.Delisted
+	}
+}
+
+// AddEvents sets the ticker history of a security from its ticker events. Events of other types are ignored.
+func (m *Master) AddEvents(id string, events []models.TickerEvent) {
+	var changes []models.TickerEvent
+	for _, e := range events {
+		if e.Type == eventTickerChange && e.TickerChange != nil {
+			changes = append(changes, e)
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Date.Before(changes[j].Date) })
+
+	sec, ok := m.securities[id]
+	if !ok {
+		sec = &Security{ID: id, CompositeFIGI: id}
+		m.securities[id] = sec
+	}
+	sec.changed = true
+	sec.Symbols = sec.Symbols[:0]
+	for i, e := range changes {
+		s := Symbol{Ticker: e.TickerChange.Ticker, From: e.Date, To: sec.Delisted}
+		if i+1 < len(changes) {
+			s.To = changes[i+1].Date
+		}
+		sec.Symbols = append(sec.Symbols, s)
+	}
+}
+
+// Security returns a security by its ID.
+func (m *Master) Security(id string) (*Security, bool) {
+	sec, ok := m.securities[id]
+	return sec, ok
+}
+
+// Securities returns every security, sorted by ID.
+func (m *Master) Securities() []*Security {
+	out := make([]*Security, 0, len(m.securities))
+	for _, sec := range m.securities {
+		out = append(out, sec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Resolve returns the security that traded under a ticker on a date. If the ticker was reused, the security that was
+// listed on the date wins.
+func (m *Master) Resolve(ticker string, d civil.Date) (*Security, bool) {
+	var match *Security
+	for _, sec := range m.Securities() {
+		if t, ok := sec.TickerOn(d); !ok || t != ticker {
+			continue
+		}
+		if sec.ListedOn(d) {
+			return sec, true
+		}
+		if match == nil {
+			match = sec
+		}
+	}
+	return match, match != nil
+}
+
+// Universe returns the securities that were listed on a date, including those that have been delisted since, sorted by
+// their ticker on the date.
+func (m *Master) Universe(d civil.Date) []Member {
+	var out []Member
+	for _, sec := range m.Securities() {
+		if !sec.ListedOn(d) {
+			continue
+		}
+		t, _ := sec.TickerOn(d)
+		out = append(out, Member{Ticker: t, Security: sec})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Ticker < out[j].Ticker })
+	return out
+}
+
+func idOf(t models.Ticker) string {
+	switch {
+	case t.CompositeFIGI != "":
+		return t.CompositeFIGI
+	case t.ShareClassFIGI != "":
+		return t.ShareClassFIGI
+	}
+	id := t.Ticker
+	if !time.Time(t.DelistedUTC).IsZero() {
+		id += "@" + civil.DateOf(time.Time(t.DelistedUTC).UTC()).String()
+	}
+	return id
+}
@@ -0,0 +1,111 @@
+package universe_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/civil"
+	"github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/rest/polygontest"
+	"github.com/polygon-io/client-go/rest/universe"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(s string) civil.Date {
+	d, _ := civil.ParseDate(s)
+	return d
+}
+
+func tickers(members []universe.Member) []string {
+	var out []string
+	for _, m := range members {
+		out = append(out, m.Ticker)
+	}
+	return out
+}
+
+func TestLoad(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+	c := s.Client("API_KEY")
+
+	params := models.ListTickersParams{}.WithMarket(models.AssetStocks)
+	m, err := universe.Load(context.Background(), c, &c.VX, params)
+	assert.Nil(t, err)
+	assert.Nil(t, params.Active)
+	requests := s.Requests()
+	assert.Equal(t, []string{
+		"/v3/reference/tickers?active=true&market=stocks",
+		"/v3/reference/tickers?active=false&market=stocks",
+	}, requests[:2])
+	// ticker events are requested concurrently
+	assert.ElementsMatch(t, []string{
+		"/vX/reference/tickers/BBG000B9XRY4/events?types=ticker_change",
+		"/vX/reference/tickers/BBG000MM2P62/events?types=ticker_change",
+	}, requests[2:])
+
+	// renames resolve across dates
+	sec, ok := m.Resolve("FB", date("2021-06-01"))
+	assert.True(t, ok)
+	assert.Equal(t, "BBG000MM2P62", sec.ID)
+	ticker, _ := sec.TickerOn(date("2023-01-03"))
+	assert.Equal(t, "META", ticker)
+	_, ok = m.Resolve("META", date("2021-06-01"))
+	assert.False(t, ok)
+	assert.Equal(t, []universe.Symbol{
+		{Ticker: "FB", From: date("2012-05-18"), To: date("2022-06-09")},
+		{Ticker: "META", From: date("2022-06-09")},
+	}, sec.Symbols)
+
+	// delisted securities stay in the universes of the dates they were listed, and securities without a listing date
+	// only join them with their first ticker
+	assert.Equal(t, []string{"AAPL", "LEH"}, tickers(m.Universe(date("2008-09-15"))))
+	assert.Equal(t, date("2012-05-18"), sec.ListedFrom())
+	assert.True(t, sec.Listed.IsZero())
+	assert.Equal(t, []string{"AAPL", "FB"}, tickers(m.Universe(date("2021-06-01"))))
+	assert.Equal(t, []string{"AAPL", "META"}, tickers(m.Universe(date("2024-01-02"))))
+
+	leh, ok := m.Security("LEH@2008-09-17")
+	assert.True(t, ok)
+	assert.Equal(t, date("2008-09-17"), leh.Delisted)
+}
+
+type failingEvents struct {
+	calls atomic.Int32
+}
+
+func (f *failingEvents) GetTickerEvents(ctx context.Context, params *models.GetTickerEventsParams, options ...models.RequestOption) (*models.GetTickerEventsResponse, error) {
+	f.calls.Add(1)
+	return nil, errors.New("unavailable")
+}
+
+func TestLoadEventsFailed(t *testing.T) {
+	s := polygontest.NewServer(polygontest.Config{Dir: "testdata"})
+	defer s.Close()
+
+	events := &failingEvents{}
+	m, err := universe.Load(context.Background(), s.Client("API_KEY"), events, models.ListTickersParams{}.WithMarket(models.AssetStocks))
+	assert.EqualError(t, err, "unavailable")
+	assert.Nil(t, m)
+	assert.NotZero(t, events.calls.Load())
+}
+
+func TestReusedTicker(t *testing.T) {
+	m := universe.New()
+	m.Add(models.Ticker{
+		Ticker:        "SQ",
+		CompositeFIGI: "BBG001",
+		ListDate:      date("2000-01-03"),
+		DelistedUTC:   models.Time(time.Date(2005, 1, 3, 0, 0, 0, 0, time.UTC)),
+	})
+	m.Add(models.Ticker{Ticker: "SQ", CompositeFIGI: "BBG002", Active: true, ListDate: date("2015-11-19")})
+
+	sec, _ := m.Resolve("SQ", date("2003-01-02"))
+	assert.Equal(t, "BBG001", sec.ID)
+	sec, _ = m.Resolve("SQ", date("2020-01-02"))
+	assert.Equal(t, "BBG002", sec.ID)
+	assert.Empty(t, m.Universe(date("2010-01-04")))
+}
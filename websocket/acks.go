@@ -0,0 +1,259 @@
+package polygonws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/models"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// SubscriptionResult is the server's answer to a subscription request for a single ticker.
+type SubscriptionResult struct {
+	Ticker string
+
+	// Err is nil if the server confirmed the subscription. Otherwise, it's the error status the server replied with
+	// (e.g. the plan isn't entitled to the topic) or the context error if no reply arrived in time.
+	Err error
+}
+
+// SubscribeAndWait subscribes to a topic and set of tickers like Subscribe, then waits until the server has replied
+// to every ticker or the context is done. The results are in the order of the tickers. Tickers that the server
+// rejects aren't resubscribed after a reconnect.
+//
+// Replies are matched to requests by the topic parameter (e.g. "T.AAPL") they mention. An error status that doesn't
+// mention one can't be matched, so it's only logged and the tickers it was about wait for the context.
+func (c *Client) SubscribeAndWait(ctx context.Context, topic Topic, tickers ...string) ([]SubscriptionResult, error) {
+	if c.rawData && c.bypassRawDataRouting {
+		return nil, errors.New("subscription replies can't be tracked when bypassing raw data routing")
+	}
+
+	a, err := c.subscribe(topic, true, tickers...)
+	if err != nil {
+		return nil, err
+	}
+
+	done := a.done
+	select {
+	case <-done:
+		return c.acks.results(a, nil), nil
+	case <-ctx.Done():
+		c.acks.cancel(a)
+		return c.acks.results(a, ctx.Err()), ctx.Err()
+	}
+}
+
+// Subscriptions returns the tickers per topic that the server has confirmed on the current connection. Subscriptions
+// that are still waiting for a reply or were rejected aren't included, and replies can't be seen when bypassing raw
+// data routing, so it's always empty then.
+func (c *Client) Subscriptions() map[Topic][]string {
+	return c.acks.subscriptions()
+}
+
+// pruneRejected stops resubscribing to tickers that the server rejected. It must be called with the client mutex held.
+func (c *Client) pruneRejected() {
+	for topic, tickers := range c.acks.takeRejected() {
+		c.subs.delete(topic, maps.Keys(tickers)...)
+	}
+}
+
+// ackExpiry is how long a subscription message that no one waits for is kept waiting for replies.
+const ackExpiry = time.Minute
+
+// ack is a subscription message waiting for the server to reply to each of its topic parameters.
+type ack struct {
+	action  models.Action
+	topic   Topic
+	tickers []string
+	params  []string
+	replied []bool
+	errs    []error
+	left    int
+	sent    time.Time
+
+	// done is closed once every parameter has a reply. It's nil if no one is waiting.
+	done chan struct{}
+}
+
+// acks matches server status messages to subscription messages and keeps track of the subscriptions that are
+// active. Replies are resolved from the process goroutine, so it has its own mutex rather than the client's.
+type acks struct {
+	mtx      sync.Mutex
+	pending  []*ack
+	active   subscriptions
+	rejected subscriptions
+
+	// track is false when status messages bypass routing, so no replies will ever be resolved
+	track bool
+}
+
+func newAcks(track bool) *acks {
+	return &acks{active: make(subscriptions), rejected: make(subscriptions), track: track}
+}
+
+// expect registers a subscription message that was just queued.
+func (t *acks) expect(action models.Action, topic Topic, tickers []string, wait bool) *ack {
+	a := &ack{
+		action:  action,
+		topic:   topic,
+		tickers: tickers,
+		params:  make([]string, len(tickers)),
+		replied: make([]bool, len(tickers)),
+		errs:    make([]error, len(tickers)),
+		left:    len(tickers),
+		sent:    time.Now(),
+	}
+	for i, ticker := range tickers {
+		a.params[i] = topic.prefix() + "." + ticker
+	}
+	if wait {
+		a.done = make(chan struct{})
+	}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if !t.track {
+		return a
+	}
+	if action == models.Subscribe {
+		t.rejected.delete(topic, tickers...)
+	}
+
+	// replies that never came (e.g. errors that don't mention a parameter) aren't waited for forever
+	pending := t.pending[:0]
+	for _, p := range t.pending {
+		if p.done != nil || a.sent.Sub(p.sent) < ackExpiry {
+			pending = append(pending, p)
+		}
+	}
+	t.pending = append(pending, a)
+	return a
+}
+
+// reset forgets the active subscriptions of a dropped connection. Messages that nobody waits for are resent from the
+// subscription cache, so only waiting requests are kept to be matched with the replies to the resent messages.
+func (t *acks) reset() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.active = make(subscriptions)
+	pending := t.pending[:0]
+	for _, a := range t.pending {
+		if a.done != nil && a.action == models.Subscribe {
+			pending = append(pending, a)
+		}
+	}
+	t.pending = pending
+}
+
+// resolve matches a "success" or "error" status message to the pending messages. It reports whether the status was
+// a reply to one of them.
+func (t *acks) resolve(status, message string) bool {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	switch status {
+	case "success":
+		action, param, ok := parseReply(message)
+		if !ok {
+			return false
+		}
+		return t.reply(func(a *ack, i int) bool { return a.action == action && a.params[i] == param }, nil)
+	case "error":
+		words := strings.FieldsFunc(message, func(r rune) bool { return r == ' ' || r == ',' || r == ':' })
+		return t.reply(func(a *ack, i int) bool { return slices.Contains(words, a.params[i]) }, errors.New(message))
+	}
+	return false
+}
+
+// reply records a reply for every pending parameter that matches, in order.
+func (t *acks) reply(match func(a *ack, i int) bool, err error) bool {
+	found := false
+	pending := t.pending[:0]
+	for _, a := range t.pending {
+		for i := range a.params {
+			if a.replied[i] || !match(a, i) {
+				continue
+			}
+			found = true
+			a.replied[i] = true
+			a.left--
+			if err != nil {
+				a.errs[i] = fmt.Errorf("server rejected %v: %w", a.params[i], err)
+				if a.action == models.Subscribe {
+					t.rejected.add(a.topic, a.tickers[i])
+				}
+			} else if a.action == models.Subscribe {
+				t.active.add(a.topic, a.tickers[i])
+			} else {
+				t.active.delete(a.topic, a.tickers[i])
+			}
+		}
+		if a.left > 0 {
+			pending = append(pending, a)
+		} else if a.done != nil {
+			close(a.done)
+		}
+	}
+	t.pending = pending
+	return found
+}
+
+// cancel stops waiting for the replies to a message. Late replies still update the active subscriptions.
+func (t *acks) cancel(a *ack) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	a.done = nil
+}
+
+// results returns the reply for each ticker of a message, using err for the ones without a reply.
+func (t *acks) results(a *ack, err error) []SubscriptionResult {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	res := make([]SubscriptionResult, len(a.tickers))
+	for i, ticker := range a.tickers {
+		res[i] = SubscriptionResult{Ticker: ticker, Err: a.errs[i]}
+		if !a.replied[i] {
+			res[i].Err = err
+		}
+	}
+	return res
+}
+
+func (t *acks) subscriptions() map[Topic][]string {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	out := make(map[Topic][]string, len(t.active))
+	for topic, tickers := range t.active {
+		keys := maps.Keys(tickers)
+		slices.Sort(keys)
+		out[topic] = keys
+	}
+	return out
+}
+
+func (t *acks) takeRejected() subscriptions {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	rejected := t.rejected
+	t.rejected = make(subscriptions)
+	return rejected
+}
+
+// parseReply parses the message of a successful reply (e.g. "subscribed to: T.AAPL").
+func parseReply(message string) (models.Action, string, bool) {
+	if param, ok := strings.CutPrefix(message, "subscribed to: "); ok {
+		return models.Subscribe, strings.TrimSpace(param), true
+	}
+	if param, ok := strings.CutPrefix(message, "unsubscribed to: "); ok {
+		return models.Unsubscribe, strings.TrimSpace(param), true
+	}
+	return "", "", false
+}
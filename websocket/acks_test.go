@@ -0,0 +1,102 @@
+package polygonws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/polygon-io/client-go/websocket/polygonwstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeAndWait(t *testing.T) {
+	s := polygonwstest.NewServer("good")
+	defer s.Close()
+	s.Reject("T.MSFT", "not authorized to access T.MSFT")
+	s.Reject("Q.TSLA", "maximum number of subscriptions reached")
+
+	c, err := New(Config{APIKey: "good", Feed: Feed(s.URL()), Market: Stocks, WaitForAuth: true})
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Nil(t, c.Connect())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// every ticker gets its own result
+	res, err := c.SubscribeAndWait(ctx, StocksTrades, "AAPL", "MSFT")
+	assert.Nil(t, err)
+	assert.Len(t, res, 2)
+	assert.Equal(t, "AAPL", res[0].Ticker)
+	assert.Nil(t, res[0].Err)
+	assert.Equal(t, "MSFT", res[1].Ticker)
+	assert.ErrorContains(t, res[1].Err, "not authorized")
+
+	// errors that don't name a parameter can't be matched, so the ticker waits for the context
+	short, cancelShort := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancelShort()
+	res, err = c.SubscribeAndWait(short, StocksQuotes, "TSLA", "NVDA")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorIs(t, res[0].Err, context.DeadlineExceeded)
+	assert.Nil(t, res[1].Err)
+
+	// only confirmed subscriptions are active, and rejected ones are dropped from the cache
+	assert.Equal(t, map[Topic][]string{StocksTrades: {"AAPL"}, StocksQuotes: {"NVDA"}}, c.Subscriptions())
+	assert.Nil(t, c.Unsubscribe(StocksQuotes, "NVDA"))
+	assert.Eventually(t, func() bool { return len(c.Subscriptions()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	c.mtx.Lock()
+	c.pruneRejected()
+	assert.Equal(t, subscriptions{StocksTrades: {"AAPL": {}}, StocksQuotes: {"TSLA": {}}}, c.subs)
+	c.mtx.Unlock()
+
+	// the context bounds the wait
+	expired, cancelExpired := context.WithCancel(context.Background())
+	cancelExpired()
+	s.SetLatency(100 * time.Millisecond)
+	res, err = c.SubscribeAndWait(expired, StocksTrades, "AMZN")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, res[0].Err, context.Canceled)
+	assert.Eventually(t, func() bool { return len(c.Subscriptions()[StocksTrades]) == 2 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestAcksResolve(t *testing.T) {
+	acks := newAcks(true)
+	first := acks.expect(models.Subscribe, StocksTrades, []string{"A", "AAPL"}, true)
+	second := acks.expect(models.Subscribe, StocksTrades, []string{"AAPL"}, true)
+
+	// unrelated messages aren't replies
+	assert.False(t, acks.resolve("success", "authenticated"))
+	assert.False(t, acks.resolve("auth_success", "authenticated"))
+
+	// a parameter is matched as a whole word, and duplicate requests get the same reply
+	assert.True(t, acks.resolve("error", "not authorized to access T.AAPL"))
+	assert.True(t, acks.resolve("success", "subscribed to: T.A"))
+	<-first.done
+	<-second.done
+	res := acks.results(first, nil)
+	assert.Nil(t, res[0].Err)
+	assert.NotNil(t, res[1].Err)
+	assert.NotNil(t, acks.results(second, nil)[0].Err)
+	assert.Empty(t, acks.pending)
+
+	// an error without pending requests is just logged
+	assert.False(t, acks.resolve("error", "unknown action"))
+
+	// so is an error that doesn't mention a parameter, which can't be matched to a request
+	third := acks.expect(models.Subscribe, StocksTrades, []string{"MSFT"}, false)
+	assert.False(t, acks.resolve("error", "internal error"))
+	assert.Nil(t, acks.results(third, nil)[0].Err)
+	assert.Len(t, acks.pending, 1)
+
+	// requests that no one waits for expire
+	third.sent = third.sent.Add(-ackExpiry)
+	acks.expect(models.Subscribe, StocksTrades, []string{"NVDA"}, false)
+	assert.Len(t, acks.pending, 1)
+	assert.Equal(t, []string{"NVDA"}, acks.pending[0].tickers)
+
+	// replies aren't tracked when they bypass routing
+	untracked := newAcks(false)
+	untracked.expect(models.Subscribe, StocksTrades, []string{"AAPL"}, false)
+	assert.Empty(t, untracked.pending)
+}
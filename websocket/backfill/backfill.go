@@ -0,0 +1,131 @@
+// Package backfill fetches the trades and quotes missed during WebSocket sequence gaps from the REST API.
+//
+// It lives outside the websocket package so that clients that don't backfill don't depend on the REST client:
+//
+//	c, err := polygonws.New(polygonws.Config{
+//		APIKey:   key,
+//		Feed:     polygonws.RealTime,
+//		Market:   polygonws.Stocks,
+//		Backfill: backfill.New(polygon.New(key)),
+//	})
+package backfill
+
+import (
+	"context"
+	"time"
+
+	restiter "github.com/polygon-io/client-go/rest/iter"
+	restmodels "github.com/polygon-io/client-go/rest/models"
+	polygonws "github.com/polygon-io/client-go/websocket"
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// pageSize is the largest page size of the trades and quotes endpoints.
+const pageSize = 50000
+
+// Lister lists trades and quotes. The Client of the rest package implements it.
+type Lister interface {
+	ListTrades(ctx context.Context, params *restmodels.ListTradesParams, options ...restmodels.RequestOption) *restiter.Iter[restmodels.Trade]
+	ListQuotes(ctx context.Context, params *restmodels.ListQuotesParams, options ...restmodels.RequestOption) *restiter.Iter[restmodels.Quote]
+}
+
+// Backfiller implements polygonws.Backfiller with the REST API.
+type Backfiller struct {
+	client Lister
+}
+
+var _ polygonws.Backfiller = (*Backfiller)(nil)
+
+// New returns a backfiller that lists the records of a gap with the given client.
+func New(client Lister) *Backfiller {
+	return &Backfiller{client: client}
+}
+
+// Trades lists the trades of a gap's symbol from its start through the millisecond of its end.
+func (b *Backfiller) Trades(ctx context.Context, gap polygonws.Gap) ([]models.EquityTrade, error) {
+	from, to := window(gap)
+	params := restmodels.ListTradesParams{Ticker: gap.Symbol}.
+		WithTimestamp(restmodels.GTE, from).
+		WithTimestamp(restmodels.LT, to).
+		WithOrder(restmodels.Asc).
+		WithSort(restmodels.Timestamp).
+		WithLimit(pageSize)
+
+	var out []models.EquityTrade
+	it := b.client.ListTrades(ctx, params)
+	for it.Next() {
+		out = append(out, FromTrade(gap.Symbol, it.Item()))
+	}
+	return out, it.Err()
+}
+
+// Quotes lists the quotes of a gap's symbol from its start through the millisecond of its end.
+func (b *Backfiller) Quotes(ctx context.Context, gap polygonws.Gap) ([]models.EquityQuote, error) {
+	from, to := window(gap)
+	params := restmodels.ListQuotesParams{Ticker: gap.Symbol}.
+		WithTimestamp(restmodels.GTE, from).
+		WithTimestamp(restmodels.LT, to).
+		WithOrder(restmodels.Asc).
+		WithSort(restmodels.Timestamp).
+		WithLimit(pageSize)
+
+	var out []models.EquityQuote
+	it := b.client.ListQuotes(ctx, params)
+	for it.Next() {
+		out = append(out, FromQuote(gap.Symbol, it.Item()))
+	}
+	return out, it.Err()
+}
+
+// FromTrade converts a trade from the REST API to a WebSocket trade of the given symbol.
+func FromTrade(symbol string, t restmodels.Trade) models.EquityTrade {
+	return models.EquityTrade{
+		EventType:                       models.EventType{EventType: "T"},
+		Symbol:                          symbol,
+		Exchange:                        int32(t.Exchange),
+		ID:                              t.ID,
+		Tape:                            t.Tape,
+		Price:                           t.Price,
+		Size:                            int64(t.Size),
+		Conditions:                      t.Conditions,
+		Timestamp:                       time.Time(t.SipTimestamp).UnixMilli(),
+		SequenceNumber:                  t.SequenceNumber,
+		TradeReportingFacilityID:        int64(t.TrfID),
+		TradeReportingFacilityTimestamp: unixMilli(time.Time(t.TrfTimestamp)),
+	}
+}
+
+// FromQuote converts a quote from the REST API to a WebSocket quote of the given symbol.
+func FromQuote(symbol string, q restmodels.Quote) models.EquityQuote {
+	out := models.EquityQuote{
+		EventType:      models.EventType{EventType: "Q"},
+		Symbol:         symbol,
+		BidExchangeID:  int32(q.BidExchange),
+		BidPrice:       q.BidPrice,
+		BidSize:        int32(q.BidSize),
+		AskExchangeID:  int32(q.AskExchange),
+		AskPrice:       q.AskPrice,
+		AskSize:        int32(q.AskSize),
+		Indicators:     q.Indicators,
+		Timestamp:      time.Time(q.SipTimestamp).UnixMilli(),
+		Tape:           q.Tape,
+		SequenceNumber: q.SequenceNumber,
+	}
+	if len(q.Conditions) > 0 {
+		out.Condition = q.Conditions[0]
+	}
+	return out
+}
+
+// window returns the timestamps that bound a gap. The streamed timestamps are truncated to milliseconds, so records
+// in the rest of the last millisecond are included by ending the window at the next one.
+func window(gap polygonws.Gap) (restmodels.Nanos, restmodels.Nanos) {
+	return restmodels.Nanos(gap.From), restmodels.Nanos(gap.To.Add(time.Millisecond))
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
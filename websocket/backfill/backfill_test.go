@@ -0,0 +1,81 @@
+package backfill_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	polygon "github.com/polygon-io/client-go/rest"
+	polygonws "github.com/polygon-io/client-go/websocket"
+	"github.com/polygon-io/client-go/websocket/backfill"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrades(t *testing.T) {
+	rc := polygon.New("API_KEY")
+	httpmock.ActivateNonDefault(rc.HTTP.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	var query string
+	httpmock.RegisterResponder("GET", `=~^https://api.polygon.io/v3/trades/AAPL`, func(req *http.Request) (*http.Response, error) {
+		query = req.URL.RawQuery
+		resp := httpmock.NewStringResponse(200, `{
+	"status": "OK",
+	"results": [
+		{"sequence_number": 5, "sip_timestamp": 2000000000, "price": 180.5, "size": 200, "exchange": 4, "trf_id": 3},
+		{"sequence_number": 8, "sip_timestamp": 4000900000, "price": 181.0, "size": 300}
+	]
+}`)
+		resp.Header.Add("Content-Type", "application/json")
+		return resp, nil
+	})
+
+	trades, err := backfill.New(rc).Trades(context.Background(), polygonws.Gap{
+		EventType:    "T",
+		Symbol:       "AAPL",
+		LastSequence: 2,
+		NextSequence: 10,
+		From:         time.UnixMilli(1000),
+		To:           time.UnixMilli(4000),
+	})
+	assert.Nil(t, err)
+
+	// the window ends before the next millisecond, so records later in the last one are included
+	assert.Equal(t, "limit=50000&order=asc&sort=timestamp&timestamp.gte=1000000000&timestamp.lt=4001000000", query)
+	assert.Len(t, trades, 2)
+	assert.Equal(t, "AAPL", trades[0].Symbol)
+	assert.Equal(t, "T", trades[0].EventType.EventType)
+	assert.Equal(t, int64(5), trades[0].SequenceNumber)
+	assert.Equal(t, int32(4), trades[0].Exchange)
+	assert.Equal(t, int64(3), trades[0].TradeReportingFacilityID)
+	assert.Equal(t, int64(2000), trades[0].Timestamp)
+	assert.Equal(t, int64(4000), trades[1].Timestamp)
+}
+
+func TestQuotes(t *testing.T) {
+	rc := polygon.New("API_KEY")
+	httpmock.ActivateNonDefault(rc.HTTP.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", `=~^https://api.polygon.io/v3/quotes/AAPL`, func(req *http.Request) (*http.Response, error) {
+		resp := httpmock.NewStringResponse(200, `{
+	"status": "OK",
+	"results": [
+		{"sequence_number": 6, "sip_timestamp": 3000000000, "bid_price": 180.1, "bid_size": 2, "ask_price": 180.2, "ask_size": 3, "conditions": [1]}
+	]
+}`)
+		resp.Header.Add("Content-Type", "application/json")
+		return resp, nil
+	})
+
+	quotes, err := backfill.New(rc).Quotes(context.Background(), polygonws.Gap{EventType: "Q", Symbol: "AAPL"})
+	assert.Nil(t, err)
+	assert.Len(t, quotes, 1)
+	assert.Equal(t, "Q", quotes[0].EventType.EventType)
+	assert.Equal(t, 180.1, quotes[0].BidPrice)
+	assert.Equal(t, int32(3), quotes[0].AskSize)
+	assert.Equal(t, int32(1), quotes[0].Condition)
+	assert.Equal(t, int64(3000), quotes[0].Timestamp)
+}
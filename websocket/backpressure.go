@@ -0,0 +1,259 @@
+package polygonws
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// defaultOutputBufferSize is the capacity of the output channel if none is configured.
+const defaultOutputBufferSize = 100000
+
+// OverflowPolicy is what the client does with a message when the output channel is full.
+type OverflowPolicy int
+
+const (
+	// Block waits until the consumer makes room in the output channel. A consumer that falls behind for long enough
+	// stalls the read loop, which can cause the connection to miss pings and reconnect.
+	Block OverflowPolicy = iota
+
+	// DropOldest discards the oldest buffered message to make room for the new one.
+	DropOldest
+
+	// DropNewest discards the new message and keeps the buffered ones.
+	DropNewest
+
+	// Conflate holds quotes and aggregates back while the output channel is full and keeps only the latest one per
+	// event type and symbol in the position of the first one held back, pushing them as soon as there is room. Other
+	// messages wait for the held back ones to be pushed and then block as with the Block policy, so they're never
+	// delivered ahead of older quotes and aggregates.
+	Conflate
+)
+
+// outbox pushes messages to the output channel according to the overflow policy and keeps track of dropped messages
+// and buffer watermarks. Pushes are only made from the process goroutine.
+type outbox struct {
+	ch       chan any
+	policy   OverflowPolicy
+	high     int
+	low      int
+	callback func(high bool, buffered int)
+	above    bool
+
+	// pending holds conflated messages in the order their keys were first held back
+	pending map[string]any
+	order   []string
+
+	mtx     sync.Mutex
+	dropped map[string]uint64
+
+	// done is closed to stop blocked pushes once nothing will read the output anymore
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newOutbox(config Config) *outbox {
+	size := config.OutputBufferSize
+	if size <= 0 {
+		size = defaultOutputBufferSize
+	}
+	return &outbox{
+		ch:       make(chan any, size),
+		policy:   config.OverflowPolicy,
+		high:     config.HighWatermark,
+		low:      config.LowWatermark,
+		callback: config.WatermarkCallback,
+		pending:  make(map[string]any),
+		dropped:  make(map[string]uint64),
+		done:     make(chan struct{}),
+	}
+}
+
+// push sends a message of the given event type to the output channel.
+func (o *outbox) push(eventType string, msg any) {
+	defer o.watermark()
+
+	switch o.policy {
+	case DropOldest:
+		for {
+			select {
+			case o.ch <- msg:
+				return
+			default:
+			}
+			select {
+			case old := <-o.ch:
+				o.drop(eventTypeOf(old))
+			default:
+			}
+		}
+	case DropNewest:
+		select {
+		case o.ch <- msg:
+		default:
+			o.drop(eventType)
+		}
+	case Conflate:
+		o.flush()
+		if key, ok := conflationKey(eventType, msg); ok && (len(o.pending) > 0 || len(o.ch) == cap(o.ch)) {
+			if _, held := o.pending[key]; held {
+				o.drop(eventType)
+			} else {
+				o.order = append(o.order, key)
+			}
+			o.pending[key] = msg
+			return
+		}
+		// the held back messages are older, so they go first
+		for len(o.order) > 0 {
+			if !o.send(o.pop()) {
+				return
+			}
+		}
+		o.send(msg)
+	default:
+		o.send(msg)
+	}
+}
+
+// send blocks until a message is pushed or the outbox is stopped, and reports whether it was pushed.
+func (o *outbox) send(msg any) bool {
+	select {
+	case o.ch <- msg:
+		return true
+	case <-o.done:
+		return false
+	}
+}
+
+// stop unblocks pending and future pushes, which discard their messages from then on.
+func (o *outbox) stop() {
+	o.stopOnce.Do(func() { close(o.done) })
+}
+
+// flush pushes as many conflated messages as there is room for without blocking.
+func (o *outbox) flush() {
+	for len(o.order) > 0 {
+		select {
+		case o.ch <- o.pending[o.order[0]]:
+			o.pop()
+		default:
+			return
+		}
+	}
+}
+
+// pop removes the oldest conflated message and returns it.
+func (o *outbox) pop() any {
+	key := o.order[0]
+	msg := o.pending[key]
+	delete(o.pending, key)
+	o.order = o.order[1:]
+	return msg
+}
+
+// tick flushes conflated messages and checks the watermarks while no new messages are arriving.
+func (o *outbox) tick() {
+	o.flush()
+	o.watermark()
+}
+
+// ticks reports whether the outbox needs to be ticked periodically.
+func (o *outbox) ticks() bool {
+	return o.policy == Conflate || (o.callback != nil && o.high > 0)
+}
+
+func (o *outbox) watermark() {
+	if o.callback == nil || o.high <= 0 {
+		return
+	}
+
+	n := len(o.ch)
+	if !o.above && n >= o.high {
+		o.above = true
+		o.callback(true, n)
+	} else if o.above && n <= o.low {
+		o.above = false
+		o.callback(false, n)
+	}
+}
+
+func (o *outbox) drop(eventType string) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.dropped[eventType]++
+}
+
+func (o *outbox) droppedCounts() map[string]uint64 {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	out := make(map[string]uint64, len(o.dropped))
+	for ev, n := range o.dropped {
+		out[ev] = n
+	}
+	return out
+}
+
+// Dropped returns the number of messages discarded by the overflow policy by event type (e.g. "T" or "Q"). Batches
+// of raw bytes pushed with BypassRawDataRouting are counted under an empty event type.
+func (c *Client) Dropped() map[string]uint64 {
+	return c.out.droppedCounts()
+}
+
+// conflationKey returns the key that a quote or aggregate is conflated under.
+func conflationKey(eventType string, msg any) (string, bool) {
+	switch m := msg.(type) {
+	case models.EquityQuote:
+		return eventType + ":" + m.Symbol, true
+	case models.ForexQuote:
+		return eventType + ":" + m.Pair, true
+	case models.CryptoQuote:
+		return eventType + ":" + m.Pair, true
+	case models.EquityAgg:
+		return eventType + ":" + m.Symbol, true
+	case models.CurrencyAgg:
+		return eventType + ":" + m.Pair, true
+	}
+	return "", false
+}
+
+// eventTypeOf returns the event type of a message that was pushed to the output channel.
+func eventTypeOf(msg any) string {
+	switch m := msg.(type) {
+	case json.RawMessage:
+		var ev models.EventType
+		_ = json.Unmarshal(m, &ev)
+		return ev.EventType
+	case models.ControlMessage:
+		return m.EventType.EventType
+	case models.EquityAgg:
+		return m.EventType.EventType
+	case models.CurrencyAgg:
+		return m.EventType.EventType
+	case models.EquityTrade:
+		return m.EventType.EventType
+	case models.CryptoTrade:
+		return m.EventType.EventType
+	case models.EquityQuote:
+		return m.EventType.EventType
+	case models.ForexQuote:
+		return m.EventType.EventType
+	case models.CryptoQuote:
+		return m.EventType.EventType
+	case models.Imbalance:
+		return m.EventType.EventType
+	case models.LimitUpLimitDown:
+		return m.EventType.EventType
+	case models.Level2Book:
+		return m.EventType.EventType
+	case models.IndexValue:
+		return m.EventType.EventType
+	case models.LaunchpadValue:
+		return m.EventType.EventType
+	case models.FairMarketValue:
+		return m.EventType.EventType
+	}
+	return ""
+}
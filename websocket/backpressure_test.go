@@ -0,0 +1,112 @@
+package polygonws
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func quotes(symbols ...string) []json.RawMessage {
+	var msgs []json.RawMessage
+	for i, sym := range symbols {
+		msgs = append(msgs, json.RawMessage(fmt.Sprintf(`{"ev":"Q","sym":"%s","bp":%d}`, sym, i)))
+	}
+	return msgs
+}
+
+func drainQuotes(c *Client) []models.EquityQuote {
+	var out []models.EquityQuote
+	for len(c.output) > 0 {
+		out = append(out, (<-c.output).(models.EquityQuote))
+	}
+	return out
+}
+
+func TestOverflowPolicy(t *testing.T) {
+	// dropping the oldest message keeps the latest ones
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, OutputBufferSize: 2, OverflowPolicy: DropOldest})
+	assert.Nil(t, err)
+	assert.Nil(t, c.route(quotes("A", "B", "C")))
+	out := drainQuotes(c)
+	assert.Equal(t, "B", out[0].Symbol)
+	assert.Equal(t, "C", out[1].Symbol)
+	assert.Equal(t, map[string]uint64{"Q": 1}, c.Dropped())
+
+	// dropping the newest message keeps the buffered ones
+	c, err = New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, OutputBufferSize: 2, OverflowPolicy: DropNewest})
+	assert.Nil(t, err)
+	assert.Nil(t, c.route(quotes("A", "B", "C", "D")))
+	out = drainQuotes(c)
+	assert.Equal(t, "A", out[0].Symbol)
+	assert.Equal(t, "B", out[1].Symbol)
+	assert.Equal(t, map[string]uint64{"Q": 2}, c.Dropped())
+
+	// conflating keeps the latest quote per symbol until there is room
+	c, err = New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, OutputBufferSize: 2, OverflowPolicy: Conflate})
+	assert.Nil(t, err)
+	assert.Nil(t, c.route(quotes("A", "B", "C", "D", "C", "C")))
+	assert.Equal(t, map[string]uint64{"Q": 2}, c.Dropped())
+	assert.Len(t, drainQuotes(c), 2)
+	c.out.tick()
+	out = drainQuotes(c)
+	assert.Len(t, out, 2)
+	assert.Equal(t, "C", out[0].Symbol)
+	assert.Equal(t, 5.0, out[0].BidPrice)
+	assert.Equal(t, "D", out[1].Symbol)
+
+	// other messages wait for the conflated ones that came before them
+	c, err = New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, OutputBufferSize: 2, OverflowPolicy: Conflate})
+	assert.Nil(t, err)
+	assert.Nil(t, c.route(quotes("A", "B", "C")))
+	done := make(chan error)
+	go func() { done <- c.route([]json.RawMessage{json.RawMessage(`{"ev":"T","sym":"A"}`)}) }()
+	var order []string
+	for len(order) < 4 {
+		switch m := (<-c.output).(type) {
+		case models.EquityQuote:
+			order = append(order, "Q:"+m.Symbol)
+		case models.EquityTrade:
+			order = append(order, "T:"+m.Symbol)
+		}
+	}
+	assert.Nil(t, <-done)
+	assert.Equal(t, []string{"Q:A", "Q:B", "Q:C", "T:A"}, order)
+}
+
+func TestEventTypeOf(t *testing.T) {
+	assert.Equal(t, "T", eventTypeOf(models.EquityTrade{EventType: models.EventType{EventType: "T"}}))
+	assert.Equal(t, "XQ", eventTypeOf(models.CryptoQuote{EventType: models.EventType{EventType: "XQ"}}))
+	assert.Equal(t, "Q", eventTypeOf(json.RawMessage(`{"ev":"Q"}`)))
+	assert.Equal(t, "", eventTypeOf([]byte("raw")))
+}
+
+func TestWatermarks(t *testing.T) {
+	var events []bool
+	c, err := New(Config{
+		APIKey:            "test",
+		Feed:              RealTime,
+		Market:            Stocks,
+		OutputBufferSize:  10,
+		HighWatermark:     3,
+		LowWatermark:      1,
+		WatermarkCallback: func(high bool, _ int) { events = append(events, high) },
+	})
+	assert.Nil(t, err)
+
+	assert.Nil(t, c.route(quotes("A", "B", "C", "D")))
+	assert.Equal(t, []bool{true}, events)
+
+	<-c.output
+	<-c.output
+	c.out.tick()
+	assert.Equal(t, []bool{true}, events)
+	<-c.output
+	c.out.tick()
+	assert.Equal(t, []bool{true, false}, events)
+
+	_, err = New(Config{APIKey: "test", HighWatermark: 1, LowWatermark: 1})
+	assert.NotNil(t, err)
+}
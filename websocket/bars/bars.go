@@ -0,0 +1,394 @@
+// Package bars builds aggregates from streams of trades.
+//
+// A Builder consumes the trades of the WebSocket client and emits bars in the shape of models.EquityAgg. Time bars can
+// have any interval (e.g. 5 seconds or 30 minutes), and tick, volume, and dollar bars close once they reach a number
+// of trades, shares, or traded value:
+//
+//	b, _ := bars.New(bars.Config{
+//		Kind:     bars.Time,
+//		Interval: 5 * time.Second,
+//		Grace:    time.Second,
+//		OnBar:    func(bar models.EquityAgg) { log.Print(bar) },
+//	})
+//	c.OnTrade(b.OnEquityTrade)
+//
+// Time bars follow the exchange clock, which is the latest trade timestamp seen across every symbol, rather than the
+// local clock. A bar is emitted once the clock passes its end plus the grace period, so trades that arrive late but
+// within the grace period are still included. Trades that arrive after their bar was emitted are dropped and counted.
+package bars
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// Kind is the rule that closes a bar.
+type Kind int
+
+const (
+	// Time bars cover fixed intervals aligned to the Unix epoch (e.g. 10:00:00 to 10:00:05 for 5 second bars).
+	Time Kind = iota
+
+	// Tick bars close after Threshold trades.
+	Tick
+
+	// Volume bars close once their volume reaches Threshold.
+	Volume
+
+	// Dollar bars close once their traded value (price times size) reaches Threshold.
+	Dollar
+)
+
+// Trade is a trade of a symbol.
+type Trade struct {
+	Symbol     string
+	Price      float64
+	Size       float64
+	Conditions []int32
+	Timestamp  time.Time
+}
+
+// FromEquityTrade converts a stock trade from the WebSocket API.
+func FromEquityTrade(t models.EquityTrade) Trade {
+	return Trade{
+		Symbol:     t.Symbol,
+		Price:      t.Price,
+		Size:       float64(t.Size),
+		Conditions: t.Conditions,
+		Timestamp:  time.UnixMilli(t.Timestamp),
+	}
+}
+
+// FromCryptoTrade converts a crypto trade from the WebSocket API.
+func FromCryptoTrade(t models.CryptoTrade) Trade {
+	return Trade{
+		Symbol:     t.Pair,
+		Price:      t.Price,
+		Size:       t.Size,
+		Conditions: t.Conditions,
+		Timestamp:  time.UnixMilli(t.Timestamp),
+	}
+}
+
+// Config is a set of builder options.
+type Config struct {
+	// Kind is the rule that closes a bar.
+	Kind Kind
+
+	// Interval is the length of time bars.
+	Interval time.Duration
+
+	// Threshold is the number of trades of tick bars, the volume of volume bars, or the traded value of dollar bars.
+	// The trade that reaches it is part of the bar, so bars can overshoot it.
+	Threshold float64
+
+	// Grace is how long time bars wait for late trades after their end on the exchange clock.
+	Grace time.Duration
+
+	// Filter is an optional function that reports whether a trade should be part of the bars (e.g. to skip trades
+	// with conditions that don't update the last price).
+	Filter func(Trade) bool
+
+	// OnBar is called with every bar in the order they close. The bars have no event type, and their accumulated
+	// volume and aggregate VWAP cover every trade of the symbol the builder has seen.
+	OnBar func(models.EquityAgg)
+}
+
+// Builder builds bars per symbol. It's safe for concurrent use.
+type Builder struct {
+	config   Config
+	interval int64
+
+	mtx     sync.Mutex
+	clock   int64
+	symbols map[string]*symbol
+	late    uint64
+
+	// next is the earliest end of an open time bar, so that the symbols are only scanned when a bar can close
+	next int64
+}
+
+type bar struct {
+	start, end int64
+	open       float64
+	high       float64
+	low        float64
+	close      float64
+	volume     float64
+	value      float64
+	trades     int
+
+	// first and last are the timestamps of the open and close trades
+	first, last int64
+}
+
+type symbol struct {
+	// open holds the time bars that haven't been emitted yet by start, or the current bar of other kinds
+	open map[int64]*bar
+
+	// closed is the end of the last emitted time bar, before which trades are late
+	closed int64
+
+	volume float64
+	value  float64
+}
+
+// New creates a builder.
+func New(config Config) (*Builder, error) {
+	switch config.Kind {
+	case Time:
+		if config.Interval < time.Millisecond {
+			return nil, errors.New("time bars need an interval of at least a millisecond")
+		}
+	case Tick, Volume, Dollar:
+		if config.Threshold <= 0 {
+			return nil, errors.New("bars need a positive threshold")
+		}
+	default:
+		return nil, errors.New("unknown kind of bar")
+	}
+	if config.Grace < 0 {
+		return nil, errors.New("grace period can't be negative")
+	}
+
+	return &Builder{
+		config:   config,
+		interval: config.Interval.Milliseconds(),
+		symbols:  make(map[string]*symbol),
+	}, nil
+}
+
+// OnEquityTrade adds a stock trade. It has the signature of an OnTrade handler.
+func (b *Builder) OnEquityTrade(t models.EquityTrade) {
+	b.Add(FromEquityTrade(t))
+}
+
+// OnCryptoTrade adds a crypto trade. It has the signature of an OnCryptoTrade handler.
+func (b *Builder) OnCryptoTrade(t models.CryptoTrade) {
+	b.Add(FromCryptoTrade(t))
+}
+
+// Add adds a trade to the bar of its symbol and emits the bars that it closes.
+func (b *Builder) Add(t Trade) {
+	if b.config.Filter != nil && !b.config.Filter(t) {
+		return
+	}
+
+	b.mtx.Lock()
+	ts := t.Timestamp.UnixMilli()
+	s, ok := b.symbols[t.Symbol]
+	if !ok {
+		s = &symbol{open: make(map[int64]*bar)}
+		b.symbols[t.Symbol] = s
+	}
+
+	var out []models.EquityAgg
+	if b.config.Kind == Time {
+		if ts < s.closed {
+			b.late++
+			b.mtx.Unlock()
+			return
+		}
+		start := ts - mod(ts, b.interval)
+		bk, ok := s.open[start]
+		if !ok {
+			bk = &bar{start: start, end: start + b.interval}
+			s.open[start] = bk
+			if b.next == 0 || bk.end < b.next {
+				b.next = bk.end
+			}
+		}
+		bk.add(t, ts)
+		s.add(t)
+		if ts > b.clock {
+			b.clock = ts
+			out = b.advance()
+		}
+	} else {
+		bk, ok := s.open[0]
+		if !ok {
+			bk = &bar{start: ts}
+			s.open[0] = bk
+		}
+		bk.add(t, ts)
+		bk.end = ts
+		s.add(t)
+		if b.full(bk) {
+			delete(s.open, 0)
+			out = append(out, s.agg(t.Symbol, bk))
+		}
+	}
+	b.mtx.Unlock()
+
+	b.emit(out)
+}
+
+// Advance moves the exchange clock forward without a trade (e.g. to the timestamp of a quote or a status message), so
+// that time bars of quiet symbols still close. Clocks earlier than the current one are ignored.
+func (b *Builder) Advance(now time.Time) {
+	b.mtx.Lock()
+	var out []models.EquityAgg
+	if ts := now.UnixMilli(); ts > b.clock {
+		b.clock = ts
+		out = b.advance()
+	}
+	b.mtx.Unlock()
+
+	b.emit(out)
+}
+
+// Flush emits every bar that hasn't closed yet, e.g. at the end of a session or a backtest.
+func (b *Builder) Flush() {
+	b.mtx.Lock()
+	var out []models.EquityAgg
+	for _, sym := range b.sorted() {
+		s := b.symbols[sym]
+		for _, bk := range s.bars() {
+			out = append(out, s.agg(sym, bk))
+			if bk.end > s.closed && b.config.Kind == Time {
+				s.closed = bk.end
+			}
+		}
+		s.open = make(map[int64]*bar)
+	}
+	b.next = 0
+	b.mtx.Unlock()
+
+	b.emit(out)
+}
+
+// Late returns the number of trades that were dropped because they arrived after their bar was emitted.
+func (b *Builder) Late() uint64 {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.late
+}
+
+// advance closes the time bars whose grace period has passed. It must be called with the mutex held.
+func (b *Builder) advance() []models.EquityAgg {
+	cutoff := b.clock - b.config.Grace.Milliseconds()
+	if b.next == 0 || cutoff < b.next {
+		return nil
+	}
+
+	var out []models.EquityAgg
+	b.next = 0
+	for _, sym := range b.sorted() {
+		s := b.symbols[sym]
+		for _, bk := range s.bars() {
+			if bk.end > cutoff {
+				if b.next == 0 || bk.end < b.next {
+					b.next = bk.end
+				}
+				break
+			}
+			out = append(out, s.agg(sym, bk))
+			delete(s.open, bk.start)
+			s.closed = bk.end
+		}
+	}
+	return out
+}
+
+func (b *Builder) full(bk *bar) bool {
+	switch b.config.Kind {
+	case Tick:
+		return float64(bk.trades) >= b.config.Threshold
+	case Volume:
+		return bk.volume >= b.config.Threshold
+	case Dollar:
+		return bk.value >= b.config.Threshold
+	}
+	return false
+}
+
+func (b *Builder) emit(out []models.EquityAgg) {
+	if b.config.OnBar == nil {
+		return
+	}
+	for _, agg := range out {
+		b.config.OnBar(agg)
+	}
+}
+
+func (b *Builder) sorted() []string {
+	syms := make([]string, 0, len(b.symbols))
+	for sym := range b.symbols {
+		syms = append(syms, sym)
+	}
+	sort.Strings(syms)
+	return syms
+}
+
+func (bk *bar) add(t Trade, ts int64) {
+	if bk.trades == 0 {
+		bk.high, bk.low = t.Price, t.Price
+	}
+	if t.Price > bk.high {
+		bk.high = t.Price
+	}
+	if t.Price < bk.low {
+		bk.low = t.Price
+	}
+	// the open and close are the first and last trades by time rather than by arrival, since late trades can arrive
+	// out of order
+	if bk.trades == 0 || ts < bk.first {
+		bk.open, bk.first = t.Price, ts
+	}
+	if bk.trades == 0 || ts >= bk.last {
+		bk.close, bk.last = t.Price, ts
+	}
+	bk.volume += t.Size
+	bk.value += t.Price * t.Size
+	bk.trades++
+}
+
+func (s *symbol) add(t Trade) {
+	s.volume += t.Size
+	s.value += t.Price * t.Size
+}
+
+// bars returns the open bars in the order they started.
+func (s *symbol) bars() []*bar {
+	out := make([]*bar, 0, len(s.open))
+	for _, bk := range s.open {
+		out = append(out, bk)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].start < out[j].start })
+	return out
+}
+
+func (s *symbol) agg(sym string, bk *bar) models.EquityAgg {
+	agg := models.EquityAgg{
+		Symbol:            sym,
+		Volume:            bk.volume,
+		AccumulatedVolume: s.volume,
+		Open:              bk.open,
+		Close:             bk.close,
+		High:              bk.high,
+		Low:               bk.low,
+		StartTimestamp:    bk.start,
+		EndTimestamp:      bk.end,
+	}
+	if bk.volume > 0 {
+		agg.VWAP = bk.value / bk.volume
+		agg.AverageSize = bk.volume / float64(bk.trades)
+	}
+	if s.volume > 0 {
+		agg.AggregateVWAP = s.value / s.volume
+	}
+	return agg
+}
+
+// mod is the remainder of a divided by b with the sign of b, so that times before the epoch are aligned too.
+func mod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
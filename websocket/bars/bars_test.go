@@ -0,0 +1,122 @@
+package bars_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/bars"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func trade(sym string, ms int64, price float64, size int64) models.EquityTrade {
+	return models.EquityTrade{Symbol: sym, Timestamp: ms, Price: price, Size: size}
+}
+
+func TestTimeBars(t *testing.T) {
+	var out []models.EquityAgg
+	b, err := bars.New(bars.Config{
+		Kind:     bars.Time,
+		Interval: 5 * time.Second,
+		Grace:    time.Second,
+		OnBar:    func(agg models.EquityAgg) { out = append(out, agg) },
+	})
+	assert.Nil(t, err)
+
+	b.OnEquityTrade(trade("AAPL", 1000, 10, 100))
+	b.OnEquityTrade(trade("AAPL", 4000, 12, 100))
+	b.OnEquityTrade(trade("AAPL", 2000, 9, 200))
+	b.OnEquityTrade(trade("MSFT", 3000, 300, 10))
+
+	// the bar stays open for late trades during the grace period
+	b.OnEquityTrade(trade("AAPL", 5500, 11, 100))
+	b.OnEquityTrade(trade("AAPL", 4500, 13, 100))
+	assert.Empty(t, out)
+
+	// once the exchange clock passes the grace period, every symbol's bar closes
+	b.OnEquityTrade(trade("AAPL", 6000, 11, 100))
+	assert.Len(t, out, 2)
+	assert.Equal(t, models.EquityAgg{
+		Symbol:            "AAPL",
+		Volume:            500,
+		AccumulatedVolume: 700,
+		VWAP:              (10*100 + 12*100 + 9*200 + 13*100) / 500.0,
+		Open:              10,
+		Close:             13,
+		High:              13,
+		Low:               9,
+		AggregateVWAP:     (10*100 + 12*100 + 9*200 + 13*100 + 11*200) / 700.0,
+		AverageSize:       125,
+		StartTimestamp:    0,
+		EndTimestamp:      5000,
+	}, out[0])
+	assert.Equal(t, "MSFT", out[1].Symbol)
+	assert.Equal(t, 300.0, out[1].Close)
+
+	// trades for a bar that was emitted are dropped
+	b.OnEquityTrade(trade("AAPL", 4900, 1, 100))
+	assert.Equal(t, uint64(1), b.Late())
+
+	// quiet symbols close when the clock advances, and flushing emits the rest
+	b.Advance(time.UnixMilli(11000))
+	assert.Len(t, out, 3)
+	assert.Equal(t, int64(5000), out[2].StartTimestamp)
+	b.OnEquityTrade(trade("AAPL", 12000, 14, 100))
+	b.Flush()
+	assert.Len(t, out, 4)
+	assert.Equal(t, 14.0, out[3].Close)
+}
+
+func TestThresholdBars(t *testing.T) {
+	var out []models.EquityAgg
+	collect := func(agg models.EquityAgg) { out = append(out, agg) }
+
+	b, err := bars.New(bars.Config{Kind: bars.Tick, Threshold: 2, OnBar: collect})
+	assert.Nil(t, err)
+	b.OnEquityTrade(trade("AAPL", 1, 10, 100))
+	b.OnEquityTrade(trade("MSFT", 2, 300, 100))
+	b.OnEquityTrade(trade("AAPL", 3, 11, 100))
+	assert.Len(t, out, 1)
+	assert.Equal(t, int64(1), out[0].StartTimestamp)
+	assert.Equal(t, int64(3), out[0].EndTimestamp)
+	assert.Equal(t, 11.0, out[0].Close)
+
+	out = nil
+	b, _ = bars.New(bars.Config{Kind: bars.Volume, Threshold: 250, OnBar: collect})
+	b.OnEquityTrade(trade("AAPL", 1, 10, 100))
+	b.OnEquityTrade(trade("AAPL", 2, 10, 100))
+	b.OnEquityTrade(trade("AAPL", 3, 10, 100))
+	assert.Len(t, out, 1)
+	assert.Equal(t, 300.0, out[0].Volume)
+
+	out = nil
+	b, _ = bars.New(bars.Config{Kind: bars.Dollar, Threshold: 1000, OnBar: collect})
+	b.OnCryptoTrade(models.CryptoTrade{Pair: "BTC-USD", Price: 30000, Size: 0.01, Timestamp: 1})
+	b.OnCryptoTrade(models.CryptoTrade{Pair: "BTC-USD", Price: 30000, Size: 0.03, Timestamp: 2})
+	assert.Len(t, out, 1)
+	assert.Equal(t, "BTC-USD", out[0].Symbol)
+	assert.InDelta(t, 0.04, out[0].Volume, 1e-9)
+}
+
+func TestFilter(t *testing.T) {
+	var out []models.EquityAgg
+	b, _ := bars.New(bars.Config{
+		Kind:      bars.Tick,
+		Threshold: 1,
+		Filter:    func(t bars.Trade) bool { return len(t.Conditions) == 0 },
+		OnBar:     func(agg models.EquityAgg) { out = append(out, agg) },
+	})
+	b.OnEquityTrade(models.EquityTrade{Symbol: "AAPL", Price: 10, Size: 1, Conditions: []int32{37}})
+	assert.Empty(t, out)
+	b.OnEquityTrade(models.EquityTrade{Symbol: "AAPL", Price: 10, Size: 1})
+	assert.Len(t, out, 1)
+}
+
+func TestNew(t *testing.T) {
+	_, err := bars.New(bars.Config{Kind: bars.Time})
+	assert.NotNil(t, err)
+	_, err = bars.New(bars.Config{Kind: bars.Volume})
+	assert.NotNil(t, err)
+	_, err = bars.New(bars.Config{Kind: bars.Time, Interval: time.Second, Grace: -time.Second})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,269 @@
+// Package book maintains live crypto level 2 order books from the XL2 messages of the CryptoL2Book topic.
+//
+// Books keeps a sorted book per pair and exchange. It's fed by registering Update as a handler, and can be seeded
+// with the full book snapshot from the REST API so that it's complete before the first updates arrive:
+//
+//	books := book.New(book.Config{})
+//	c.OnLevel2Book(books.Update)
+//	_ = c.Subscribe(polygonws.CryptoL2Book, "BTC-USD")
+//	_ = books.Seed(ctx, rest, "BTC-USD")
+//
+//	snap, _ := books.Consolidated("BTC-USD")
+//	mid, _ := snap.Mid()
+//
+// Every read returns a Snapshot, which is a copy that can be used without holding any lock.
+package book
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	restmodels "github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// Side is a side of a book.
+type Side int
+
+const (
+	Bid Side = iota
+	Ask
+)
+
+// Level is the total size resting at a price.
+type Level struct {
+	Price float64
+	Size  float64
+}
+
+// Config is a set of book options.
+type Config struct {
+	// Snapshots treats every XL2 message as the complete book of its exchange. Omitting this treats messages as
+	// updates to the levels they contain, where a size of zero removes a level.
+	Snapshots bool
+}
+
+// Snapshotter fetches the full book of a crypto ticker. The Client of the rest package implements it.
+type Snapshotter interface {
+	GetCryptoFullBookSnapshot(ctx context.Context, params *restmodels.GetCryptoFullBookSnapshotParams, opts ...restmodels.RequestOption) (*restmodels.GetCryptoFullBookSnapshotResponse, error)
+}
+
+// Books is a set of order books per pair and exchange. It's safe for concurrent use.
+type Books struct {
+	snapshots bool
+
+	mtx   sync.RWMutex
+	books map[string]map[int32]*book
+}
+
+// book is the sorted book of a single pair on a single exchange. Bids are sorted from the highest price and asks from
+// the lowest, so the best level of each side comes first.
+type book struct {
+	bids    []Level
+	asks    []Level
+	updated time.Time
+}
+
+// New creates an empty set of books.
+func New(config Config) *Books {
+	return &Books{snapshots: config.Snapshots, books: make(map[string]map[int32]*book)}
+}
+
+// Update applies an XL2 message. Messages older than the last update of their book are ignored, which covers messages
+// that were already part of a seeded snapshot. It has the signature of an OnLevel2Book handler.
+func (b *Books) Update(msg models.Level2Book) {
+	ts := time.UnixMilli(msg.Timestamp)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	bk := b.book(msg.Pair, msg.ExchangeID)
+	if ts.Before(bk.updated) {
+		return
+	}
+	if b.snapshots {
+		bk.bids, bk.asks = nil, nil
+	}
+	for _, l := range msg.BidPrices {
+		if len(l) >= 2 {
+			bk.bids = set(bk.bids, Bid, l[0], l[1])
+		}
+	}
+	for _, l := range msg.AskPrices {
+		if len(l) >= 2 {
+			bk.asks = set(bk.asks, Ask, l[0], l[1])
+		}
+	}
+	bk.updated = ts
+}
+
+// SeedSnapshot replaces the books of a pair (e.g. "BTC-USD") with a full book snapshot from the REST API.
+func (b *Books) SeedSnapshot(pair string, snap restmodels.FullBookSnapshot) {
+	books := make(map[int32]*book)
+	updated := time.Time(snap.Updated)
+	add := func(quotes []restmodels.OrderBookQuote, side Side) {
+		for _, q := range quotes {
+			for x, size := range q.ExchangeToShares {
+				id, err := strconv.ParseInt(x, 10, 32)
+				if err != nil {
+					continue
+				}
+				bk, ok := books[int32(id)]
+				if !ok {
+					bk = &book{updated: updated}
+					books[int32(id)] = bk
+				}
+				if side == Bid {
+					bk.bids = set(bk.bids, Bid, q.Price, size)
+				} else {
+					bk.asks = set(bk.asks, Ask, q.Price, size)
+				}
+			}
+		}
+	}
+	add(snap.Bids, Bid)
+	add(snap.Asks, Ask)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.books[pair] = books
+}
+
+// Seed fetches the full book of a pair (e.g. "BTC-USD") and replaces its books with it.
+func (b *Books) Seed(ctx context.Context, client Snapshotter, pair string) error {
+	res, err := client.GetCryptoFullBookSnapshot(ctx, &restmodels.GetCryptoFullBookSnapshotParams{Ticker: Ticker(pair)})
+	if err != nil {
+		return err
+	}
+	b.SeedSnapshot(pair, res.Data)
+	return nil
+}
+
+// Book returns a copy of the book of a pair on an exchange.
+func (b *Books) Book(pair string, exchange int32) (Snapshot, bool) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	bk, ok := b.books[pair][exchange]
+	if !ok {
+		return Snapshot{}, false
+	}
+	return Snapshot{
+		Pair:     pair,
+		Exchange: exchange,
+		Bids:     append([]Level(nil), bk.bids...),
+		Asks:     append([]Level(nil), bk.asks...),
+		Updated:  bk.updated,
+	}, true
+}
+
+// Consolidated returns the book of a pair across every exchange, where the size of each price level is the sum of its
+// size on each exchange. The exchange of the snapshot is zero.
+func (b *Books) Consolidated(pair string) (Snapshot, bool) {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	books, ok := b.books[pair]
+	if !ok {
+		return Snapshot{}, false
+	}
+	snap := Snapshot{Pair: pair}
+	for _, bk := range books {
+		for _, l := range bk.bids {
+			snap.Bids = add(snap.Bids, Bid, l.Price, l.Size)
+		}
+		for _, l := range bk.asks {
+			snap.Asks = add(snap.Asks, Ask, l.Price, l.Size)
+		}
+		if bk.updated.After(snap.Updated) {
+			snap.Updated = bk.updated
+		}
+	}
+	return snap, true
+}
+
+// Pairs returns the sorted pairs that have a book.
+func (b *Books) Pairs() []string {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	pairs := make([]string, 0, len(b.books))
+	for pair := range b.books {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// Exchanges returns the sorted IDs of the exchanges that have a book for a pair.
+func (b *Books) Exchanges(pair string) []int32 {
+	b.mtx.RLock()
+	defer b.mtx.RUnlock()
+
+	ids := make([]int32, 0, len(b.books[pair]))
+	for id := range b.books[pair] {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (b *Books) book(pair string, exchange int32) *book {
+	books, ok := b.books[pair]
+	if !ok {
+		books = make(map[int32]*book)
+		b.books[pair] = books
+	}
+	bk, ok := books[exchange]
+	if !ok {
+		bk = &book{}
+		books[exchange] = bk
+	}
+	return bk
+}
+
+// Ticker returns the REST ticker of a WebSocket pair (e.g. "X:BTCUSD" for "BTC-USD").
+func Ticker(pair string) string {
+	return "X:" + strings.ReplaceAll(pair, "-", "")
+}
+
+// search returns the index of a price on one side of a book, or where it would be inserted.
+func search(levels []Level, side Side, price float64) int {
+	if side == Bid {
+		return sort.Search(len(levels), func(i int) bool { return levels[i].Price <= price })
+	}
+	return sort.Search(len(levels), func(i int) bool { return levels[i].Price >= price })
+}
+
+// set replaces the size of a price level, removing it if the size is zero.
+func set(levels []Level, side Side, price, size float64) []Level {
+	i := search(levels, side, price)
+	found := i < len(levels) && levels[i].Price == price
+	switch {
+	case size <= 0 && found:
+		return append(levels[:i], levels[i+1:]...)
+	case size <= 0:
+		return levels
+	case found:
+		levels[i].Size = size
+		return levels
+	}
+	levels = append(levels, Level{})
+	copy(levels[i+1:], levels[i:])
+	levels[i] = Level{Price: price, Size: size}
+	return levels
+}
+
+// add adds size to a price level.
+func add(levels []Level, side Side, price, size float64) []Level {
+	i := search(levels, side, price)
+	if i < len(levels) && levels[i].Price == price {
+		levels[i].Size += size
+		return levels
+	}
+	return set(levels, side, price, size)
+}
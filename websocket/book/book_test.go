@@ -0,0 +1,150 @@
+package book_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	restmodels "github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/websocket/book"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func msg(exchange int32, ts int64, bids, asks [][]float64) models.Level2Book {
+	return models.Level2Book{Pair: "BTC-USD", ExchangeID: exchange, Timestamp: ts, BidPrices: bids, AskPrices: asks}
+}
+
+func TestUpdate(t *testing.T) {
+	books := book.New(book.Config{})
+	books.Update(msg(1, 1, [][]float64{{100, 1}, {102, 2}, {101, 3}}, [][]float64{{104, 1}, {103, 2}}))
+
+	snap, ok := books.Book("BTC-USD", 1)
+	assert.True(t, ok)
+	assert.Equal(t, []book.Level{{Price: 102, Size: 2}, {Price: 101, Size: 3}, {Price: 100, Size: 1}}, snap.Bids)
+	assert.Equal(t, []book.Level{{Price: 103, Size: 2}, {Price: 104, Size: 1}}, snap.Asks)
+
+	// updates replace and remove levels, and stale messages are ignored
+	books.Update(msg(1, 2, [][]float64{{102, 0}, {101, 5}}, [][]float64{{103.5, 1}}))
+	books.Update(msg(1, 1, [][]float64{{200, 1}}, nil))
+	snap, _ = books.Book("BTC-USD", 1)
+	assert.Equal(t, []book.Level{{Price: 101, Size: 5}, {Price: 100, Size: 1}}, snap.Bids)
+	assert.Equal(t, []book.Level{{Price: 103, Size: 2}, {Price: 103.5, Size: 1}, {Price: 104, Size: 1}}, snap.Asks)
+	assert.Equal(t, time.UnixMilli(2), snap.Updated)
+
+	// snapshots are copies
+	snap.Bids[0].Size = 100
+	again, _ := books.Book("BTC-USD", 1)
+	assert.Equal(t, 5.0, again.Bids[0].Size)
+
+	// the consolidated book sums every exchange
+	books.Update(msg(2, 3, [][]float64{{101, 1}, {100.5, 1}}, [][]float64{{103, 1}}))
+	snap, ok = books.Consolidated("BTC-USD")
+	assert.True(t, ok)
+	assert.Equal(t, []book.Level{{Price: 101, Size: 6}, {Price: 100.5, Size: 1}, {Price: 100, Size: 1}}, snap.Bids)
+	assert.Equal(t, book.Level{Price: 103, Size: 3}, snap.Asks[0])
+	assert.Equal(t, []string{"BTC-USD"}, books.Pairs())
+	assert.Equal(t, []int32{1, 2}, books.Exchanges("BTC-USD"))
+
+	_, ok = books.Book("ETH-USD", 1)
+	assert.False(t, ok)
+}
+
+func TestUpdateSnapshots(t *testing.T) {
+	books := book.New(book.Config{Snapshots: true})
+	books.Update(msg(1, 1, [][]float64{{100, 1}, {99, 1}}, [][]float64{{101, 1}}))
+	books.Update(msg(1, 2, [][]float64{{98, 1}}, [][]float64{{102, 1}}))
+
+	snap, _ := books.Book("BTC-USD", 1)
+	assert.Equal(t, []book.Level{{Price: 98, Size: 1}}, snap.Bids)
+	assert.Equal(t, []book.Level{{Price: 102, Size: 1}}, snap.Asks)
+}
+
+func TestSnapshot(t *testing.T) {
+	snap := book.Snapshot{
+		Bids: []book.Level{{Price: 100, Size: 3}, {Price: 99, Size: 2}, {Price: 98, Size: 5}},
+		Asks: []book.Level{{Price: 101, Size: 1}, {Price: 102, Size: 4}},
+	}
+
+	mid, ok := snap.Mid()
+	assert.True(t, ok)
+	assert.Equal(t, 100.5, mid)
+	spread, _ := snap.Spread()
+	assert.Equal(t, 1.0, spread)
+	micro, _ := snap.Microprice()
+	assert.Equal(t, (100*1+101*3)/4.0, micro)
+
+	assert.Equal(t, 5.0, snap.CumulativeSize(book.Bid, 99))
+	assert.Equal(t, 10.0, snap.CumulativeSize(book.Bid, 0))
+	assert.Equal(t, 0.0, snap.CumulativeSize(book.Bid, 100.5))
+	assert.Equal(t, 5.0, snap.CumulativeSize(book.Ask, 102))
+
+	top := snap.Depth(1)
+	assert.Len(t, top.Bids, 1)
+	assert.Len(t, top.Asks, 1)
+	assert.Len(t, snap.Bids, 3)
+	assert.Empty(t, snap.Depth(-1).Bids)
+	assert.Empty(t, snap.Depth(-1).Asks)
+
+	_, ok = book.Snapshot{Bids: snap.Bids}.Mid()
+	assert.False(t, ok)
+}
+
+type snapshotter struct {
+	ticker string
+}
+
+func (s *snapshotter) GetCryptoFullBookSnapshot(_ context.Context, params *restmodels.GetCryptoFullBookSnapshotParams, _ ...restmodels.RequestOption) (*restmodels.GetCryptoFullBookSnapshotResponse, error) {
+	s.ticker = params.Ticker
+	return &restmodels.GetCryptoFullBookSnapshotResponse{Data: restmodels.FullBookSnapshot{
+		Bids: []restmodels.OrderBookQuote{
+			{Price: 16303.17, ExchangeToShares: map[string]float64{"1": 2}},
+			{Price: 16302.94, ExchangeToShares: map[string]float64{"1": 0.02859424, "6": 0.023455}},
+		},
+		Asks: []restmodels.OrderBookQuote{
+			{Price: 16304, ExchangeToShares: map[string]float64{"6": 1}},
+		},
+		Ticker:  "X:BTCUSD",
+		Updated: restmodels.Nanos(time.UnixMilli(1605295074162)),
+	}}, nil
+}
+
+func TestSeed(t *testing.T) {
+	books := book.New(book.Config{})
+	client := &snapshotter{}
+	assert.Nil(t, books.Seed(context.Background(), client, "BTC-USD"))
+	assert.Equal(t, "X:BTCUSD", client.ticker)
+	assert.Equal(t, []int32{1, 6}, books.Exchanges("BTC-USD"))
+
+	snap, _ := books.Book("BTC-USD", 6)
+	assert.Equal(t, []book.Level{{Price: 16302.94, Size: 0.023455}}, snap.Bids)
+	assert.Equal(t, []book.Level{{Price: 16304, Size: 1}}, snap.Asks)
+
+	// updates from before the snapshot are already part of it
+	books.Update(msg(6, 1605295074000, [][]float64{{16310, 1}}, nil))
+	books.Update(msg(6, 1605295075000, nil, [][]float64{{16304, 0}}))
+	snap, _ = books.Book("BTC-USD", 6)
+	assert.Len(t, snap.Bids, 1)
+	assert.Empty(t, snap.Asks)
+}
+
+func TestConcurrentReaders(t *testing.T) {
+	books := book.New(book.Config{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if snap, ok := books.Consolidated("BTC-USD"); ok {
+					_, _ = snap.Mid()
+				}
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		books.Update(msg(int32(j%3), int64(j), [][]float64{{float64(100 - j%5), 1}}, [][]float64{{float64(101 + j%5), 1}}))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,101 @@
+package book
+
+import "time"
+
+// Snapshot is a copy of a book at a point in time. Bids are sorted from the highest price and asks from the lowest.
+type Snapshot struct {
+	Pair     string
+	Exchange int32
+	Bids     []Level
+	Asks     []Level
+	Updated  time.Time
+}
+
+// BestBid returns the highest bid.
+func (s Snapshot) BestBid() (Level, bool) {
+	if len(s.Bids) == 0 {
+		return Level{}, false
+	}
+	return s.Bids[0], true
+}
+
+// BestAsk returns the lowest ask.
+func (s Snapshot) BestAsk() (Level, bool) {
+	if len(s.Asks) == 0 {
+		return Level{}, false
+	}
+	return s.Asks[0], true
+}
+
+// Depth returns the snapshot limited to the best n levels of each side. A depth of zero or less leaves both sides
+// empty.
+func (s Snapshot) Depth(n int) Snapshot {
+	n = max(n, 0)
+	if n < len(s.Bids) {
+		s.Bids = s.Bids[:n:n]
+	}
+	if n < len(s.Asks) {
+		s.Asks = s.Asks[:n:n]
+	}
+	return s
+}
+
+// Mid returns the midpoint between the best bid and ask. It's false if either side is empty.
+func (s Snapshot) Mid() (float64, bool) {
+	bid, ask, ok := s.top()
+	if !ok {
+		return 0, false
+	}
+	return (bid.Price + ask.Price) / 2, true
+}
+
+// Spread returns the best ask minus the best bid, which is negative for a crossed book. It's false if either side is
+// empty.
+func (s Snapshot) Spread() (float64, bool) {
+	bid, ask, ok := s.top()
+	if !ok {
+		return 0, false
+	}
+	return ask.Price - bid.Price, true
+}
+
+// Microprice returns the midpoint weighted by the size on the opposite side of the book, which leans towards the side
+// that is more likely to be hit next. It's false if either side is empty.
+func (s Snapshot) Microprice() (float64, bool) {
+	bid, ask, ok := s.top()
+	if !ok {
+		return 0, false
+	}
+	return (bid.Price*ask.Size + ask.Price*bid.Size) / (bid.Size + ask.Size), true
+}
+
+// CumulativeSize returns the total size of one side of the book from the best level up to and including a price,
+// i.e. the size that an order crossing to that price could fill against. For bids, these are the levels priced at or
+// above it, and for asks, the levels priced at or below it.
+func (s Snapshot) CumulativeSize(side Side, price float64) float64 {
+	levels := s.Bids
+	if side == Ask {
+		levels = s.Asks
+	}
+
+	var total float64
+	for _, l := range levels {
+		if (side == Bid && l.Price < price) || (side == Ask && l.Price > price) {
+			break
+		}
+		total += l.Size
+	}
+	return total
+}
+
+func (s Snapshot) top() (Level, Level, bool) {
+	bid, ok := s.BestBid()
+	if !ok {
+		return Level{}, Level{}, false
+	}
+	ask, ok := s.BestAsk()
+	if !ok {
+		return Level{}, Level{}, false
+	}
+	return bid, ask, true
+}
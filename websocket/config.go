@@ -30,6 +30,40 @@ type Config struct {
 	// If this flag is `true`, it's up to the caller to handle all message types including auth and subscription responses.
 	BypassRawDataRouting bool
 
+	// OutputBufferSize is the capacity of the output channel. Omitting this uses a buffer of 100,000 messages.
+	OutputBufferSize int
+
+	// OverflowPolicy is what the client does with a message when the output channel is full (e.g. DropOldest).
+	// Omitting this blocks until the consumer catches up. Messages discarded by the policy are counted by Dropped.
+	OverflowPolicy OverflowPolicy
+
+	// HighWatermark and LowWatermark are numbers of buffered output messages. WatermarkCallback is called with
+	// high set to true when the buffer fills up to HighWatermark, and with high set to false when it drains back
+	// down to LowWatermark. The callback is called from the client's processing goroutine, so it should return
+	// quickly. Omitting HighWatermark disables the callback.
+	HighWatermark     int
+	LowWatermark      int
+	WatermarkCallback func(high bool, buffered int)
+
+	// DetectGaps enables sequence tracking of trades and quotes. When the first trade or quote of a symbol after a
+	// reconnect jumps further past the last one before it than the symbol's sequence numbers did within a connection,
+	// a Gap is pushed to Output (or the OnGap handlers).
+	DetectGaps bool
+
+	// Backfill is an optional source of the trades and quotes of every gap, which implies DetectGaps. The backfill
+	// package provides one that uses the REST API. The missing records are pushed like regular messages after the gap,
+	// with their Backfilled flag set. Gaps are backfilled by a few workers at a time, and overlapping gaps of the same
+	// stream are merged.
+	Backfill Backfiller
+
+	// Recorder is an optional capture that every raw frame read from the server is written to, so that the session
+	// can be reproduced later with a ReplayClient. The caller is responsible for closing it.
+	Recorder *Recorder
+
+	// WaitForAuth makes Connect wait for the server to accept or reject the API key instead of returning as soon as
+	// the connection is dialed. Connect returns an error if authentication fails or takes longer than 10 seconds.
+	WaitForAuth bool
+
 	// ReconnectCallback is a callback that is triggered on automatic reconnects by the websocket client.
 	// This can be useful for implementing additional logic around reconnect paths e.g. logging, metrics
 	// or managing the connection. The callback function takes as input an error type which will be non-nil
@@ -46,6 +80,10 @@ func (c *Config) validate() error {
 		return errors.New("API key is required")
 	}
 
+	if c.HighWatermark > 0 && c.LowWatermark >= c.HighWatermark {
+		return errors.New("low watermark must be below the high watermark")
+	}
+
 	if c.Log == nil {
 		c.Log = &nopLogger{}
 	}
@@ -0,0 +1,288 @@
+package polygonws
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+const (
+	// backfillTimeout bounds the REST calls made to backfill a single gap.
+	backfillTimeout = time.Minute
+
+	// backfillWorkers is the number of gaps that are backfilled at the same time. A reconnect on a wildcard feed can
+	// open a gap for every symbol at once, so gaps are queued rather than each getting its own requests.
+	backfillWorkers = 4
+
+	// maxQueuedBackfills is the number of gaps that can wait for a worker. Gaps beyond it are still pushed but aren't
+	// backfilled.
+	maxQueuedBackfills = 10000
+)
+
+// Gap is a window of a symbol's trades or quotes that may have been missed while the client was reconnecting. Gaps
+// are pushed to Output or passed to the OnGap handlers.
+type Gap struct {
+	// EventType is the event type of the stream with the gap ("T" for trades or "Q" for quotes).
+	EventType string
+
+	// Symbol is the ticker symbol of the stream.
+	Symbol string
+
+	// LastSequence is the sequence number of the last message received before the gap.
+	LastSequence int64
+
+	// NextSequence is the sequence number of the first message received after the gap.
+	NextSequence int64
+
+	// From and To are the timestamps of the messages on either side of the gap.
+	From time.Time
+	To   time.Time
+}
+
+// Backfiller fetches the trades and quotes that were missed during a gap. It should return every record of the symbol
+// from the millisecond of gap.From through the millisecond of gap.To, since the timestamps of streamed messages are
+// truncated to milliseconds; records outside the gap's sequence numbers are discarded by the client. The backfill
+// package implements it with the REST client.
+type Backfiller interface {
+	Trades(ctx context.Context, gap Gap) ([]models.EquityTrade, error)
+	Quotes(ctx context.Context, gap Gap) ([]models.EquityQuote, error)
+}
+
+// backfill is a batch of backfilled messages handed back to the process goroutine to be pushed.
+type backfill struct {
+	eventType string
+	msgs      []any
+}
+
+// backfillQueue is the queue of gaps waiting to be backfilled. A gap that overlaps a queued gap of the same stream is
+// merged into it.
+type backfillQueue struct {
+	mtx    sync.Mutex
+	gaps   []*Gap
+	latest map[string]*Gap
+
+	// wake has a value while gaps are queued
+	wake chan struct{}
+}
+
+func newBackfillQueue() *backfillQueue {
+	return &backfillQueue{latest: make(map[string]*Gap), wake: make(chan struct{}, 1)}
+}
+
+// push queues a gap and reports whether it was queued or merged.
+func (q *backfillQueue) push(gap Gap) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	key := gap.EventType + ":" + gap.Symbol
+	if p, ok := q.latest[key]; ok && gap.LastSequence < p.NextSequence && p.LastSequence < gap.NextSequence {
+		p.LastSequence = min(p.LastSequence, gap.LastSequence)
+		p.NextSequence = max(p.NextSequence, gap.NextSequence)
+		if gap.From.Before(p.From) {
+			p.From = gap.From
+		}
+		if gap.To.After(p.To) {
+			p.To = gap.To
+		}
+		return true
+	}
+	if len(q.gaps) >= maxQueuedBackfills {
+		return false
+	}
+
+	q.gaps = append(q.gaps, &gap)
+	q.latest[key] = &gap
+	q.signal()
+	return true
+}
+
+// pop removes the oldest queued gap.
+func (q *backfillQueue) pop() (Gap, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if len(q.gaps) == 0 {
+		return Gap{}, false
+	}
+	gap := q.gaps[0]
+	q.gaps[0] = nil
+	q.gaps = q.gaps[1:]
+	if key := gap.EventType + ":" + gap.Symbol; q.latest[key] == gap {
+		delete(q.latest, key)
+	}
+	if len(q.gaps) > 0 {
+		q.signal()
+	}
+	return *gap, true
+}
+
+func (q *backfillQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+type sequence struct {
+	number    int64
+	timestamp int64
+	epoch     int64
+
+	// step is the largest increase between consecutive messages seen within a connection
+	step int64
+}
+
+// gapTracker keeps the last sequence number seen per event type and symbol. Sequence numbers increase per symbol but
+// aren't always consecutive, so a jump in the middle of a connection isn't a gap. Only the first message of a symbol
+// after a reconnect is compared with the last one before it, and it's only a gap if the jump between them is larger
+// than any jump the symbol made within a connection. A stream whose numbers have always been consecutive has a gap as
+// soon as a number is skipped.
+type gapTracker struct {
+	epoch atomic.Int64
+	last  map[string]map[string]sequence
+}
+
+func newGapTracker() *gapTracker {
+	return &gapTracker{last: make(map[string]map[string]sequence)}
+}
+
+// reconnected marks the start of a new connection.
+func (g *gapTracker) reconnected() {
+	g.epoch.Add(1)
+}
+
+// observe records a message and returns the gap that precedes it, if any.
+func (g *gapTracker) observe(eventType, symbol string, number, timestamp int64) (Gap, bool) {
+	if number == 0 {
+		return Gap{}, false
+	}
+
+	symbols, ok := g.last[eventType]
+	if !ok {
+		symbols = make(map[string]sequence)
+		g.last[eventType] = symbols
+	}
+
+	epoch := g.epoch.Load()
+	prev, seen := symbols[symbol]
+	if seen && number <= prev.number {
+		return Gap{}, false // a duplicate or out of order message
+	}
+
+	next := sequence{number: number, timestamp: timestamp, epoch: epoch, step: max(prev.step, 1)}
+	if !seen || prev.epoch == epoch {
+		if seen {
+			next.step = max(next.step, number-prev.number)
+		}
+		symbols[symbol] = next
+		return Gap{}, false
+	}
+	symbols[symbol] = next
+
+	if number-prev.number <= prev.step {
+		return Gap{}, false
+	}
+	return Gap{
+		EventType:    eventType,
+		Symbol:       symbol,
+		LastSequence: prev.number,
+		NextSequence: number,
+		From:         time.UnixMilli(prev.timestamp),
+		To:           time.UnixMilli(timestamp),
+	}, true
+}
+
+// detectGap checks trades and quotes for a gap, pushes it, and queues a backfill if one is configured.
+func (c *Client) detectGap(eventType string, msg any) {
+	var gap Gap
+	var ok bool
+	switch m := msg.(type) {
+	case models.EquityTrade:
+		gap, ok = c.gaps.observe(eventType, m.Symbol, m.SequenceNumber, m.Timestamp)
+	case models.EquityQuote:
+		gap, ok = c.gaps.observe(eventType, m.Symbol, m.SequenceNumber, m.Timestamp)
+	}
+	if !ok {
+		return
+	}
+
+	c.log.Infof("sequence gap in '%v' %v: %v to %v", eventType, sanitize(gap.Symbol), gap.LastSequence, gap.NextSequence)
+	c.deliver("gap", gap)
+	if c.backfiller != nil && !c.backfillQueue.push(gap) {
+		c.log.Errorf("too many gaps queued: not backfilling '%v' %v", eventType, sanitize(gap.Symbol))
+	}
+}
+
+// startBackfill starts the backfill workers, which stop with the process goroutine.
+func (c *Client) startBackfill() {
+	for i := 0; i < backfillWorkers; i++ {
+		c.ptomb.Go(c.backfillWorker)
+	}
+}
+
+func (c *Client) backfillWorker() error {
+	ctx := c.ptomb.Context(context.Background())
+	for {
+		select {
+		case <-c.ptomb.Dying():
+			return nil
+		case <-c.backfillQueue.wake:
+		}
+		for gap, ok := c.backfillQueue.pop(); ok; gap, ok = c.backfillQueue.pop() {
+			c.backfill(ctx, gap)
+		}
+	}
+}
+
+// backfill fetches the records of a gap and hands them to the process goroutine.
+func (c *Client) backfill(ctx context.Context, gap Gap) {
+	ctx, cancel := context.WithTimeout(ctx, backfillTimeout)
+	defer cancel()
+
+	inGap := func(number int64) bool {
+		return number > gap.LastSequence && number < gap.NextSequence
+	}
+
+	var msgs []any
+	var err error
+	switch gap.EventType {
+	case "T":
+		var trades []models.EquityTrade
+		trades, err = c.backfiller.Trades(ctx, gap)
+		for _, t := range trades {
+			if inGap(t.SequenceNumber) {
+				t.Backfilled = true
+				msgs = append(msgs, t)
+			}
+		}
+	case "Q":
+		var quotes []models.EquityQuote
+		quotes, err = c.backfiller.Quotes(ctx, gap)
+		for _, q := range quotes {
+			if inGap(q.SequenceNumber) {
+				q.Backfilled = true
+				msgs = append(msgs, q)
+			}
+		}
+	}
+	if err != nil {
+		c.log.Errorf("failed to backfill '%v' %v: %v", gap.EventType, sanitize(gap.Symbol), err)
+		return
+	}
+	if len(msgs) == 0 {
+		return
+	}
+
+	select {
+	case c.backfills <- backfill{eventType: gap.EventType, msgs: msgs}:
+	case <-c.ptomb.Dying():
+	}
+}
+
+// OnGap registers a handler for sequence gaps detected after a reconnect. See OnAgg for how handlers are called.
+func (c *Client) OnGap(fn func(Gap)) {
+	on(c, fn)
+}
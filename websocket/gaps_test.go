@@ -0,0 +1,184 @@
+package polygonws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBackfiller returns fixed trades and records the gaps it was asked for.
+type fakeBackfiller struct {
+	trades []models.EquityTrade
+
+	mtx  sync.Mutex
+	gaps []Gap
+}
+
+func (b *fakeBackfiller) Trades(_ context.Context, gap Gap) ([]models.EquityTrade, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.gaps = append(b.gaps, gap)
+	return b.trades, nil
+}
+
+func (b *fakeBackfiller) Quotes(_ context.Context, gap Gap) ([]models.EquityQuote, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.gaps = append(b.gaps, gap)
+	return nil, nil
+}
+
+func TestGapDetection(t *testing.T) {
+	b := &fakeBackfiller{trades: []models.EquityTrade{
+		{Symbol: "AAPL", SequenceNumber: 2, Timestamp: 1000},
+		{Symbol: "AAPL", SequenceNumber: 5, Timestamp: 2000, Exchange: 4},
+		{Symbol: "AAPL", SequenceNumber: 8, Timestamp: 3000},
+		{Symbol: "AAPL", SequenceNumber: 10, Timestamp: 4000},
+	}}
+
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Backfill: b})
+	assert.Nil(t, err)
+	c.startBackfill()
+	defer c.ptomb.Kill(nil)
+
+	route := func(data string) {
+		var msgs []json.RawMessage
+		assert.Nil(t, json.Unmarshal([]byte(data), &msgs))
+		assert.Nil(t, c.route(msgs))
+	}
+
+	// sequence numbers aren't always consecutive, so jumps within a connection aren't gaps
+	route(`[{"ev":"T","sym":"AAPL","q":1,"t":1000},{"ev":"T","sym":"AAPL","q":2,"t":1000},{"ev":"Q","sym":"AAPL","q":3,"t":1000},` +
+		`{"ev":"T","sym":"MSFT","q":1,"t":1000},{"ev":"T","sym":"MSFT","q":6,"t":1000}]`)
+	assert.Len(t, c.output, 5)
+	for len(c.output) > 0 {
+		<-c.output
+	}
+
+	c.gaps.reconnected()
+	// MSFT moved by no more than it did within the connection, so only the AAPL trades have a gap
+	route(`[{"ev":"T","sym":"AAPL","q":10,"t":4000},{"ev":"Q","sym":"AAPL","q":4,"t":4000},{"ev":"T","sym":"MSFT","q":9,"t":4000}]`)
+	assert.Len(t, c.output, 4)
+	gap, ok := (<-c.output).(Gap)
+	assert.True(t, ok)
+	assert.Equal(t, Gap{
+		EventType:    "T",
+		Symbol:       "AAPL",
+		LastSequence: 2,
+		NextSequence: 10,
+		From:         time.UnixMilli(1000),
+		To:           time.UnixMilli(4000),
+	}, gap)
+	assert.Equal(t, int64(10), (<-c.output).(models.EquityTrade).SequenceNumber)
+	_, ok = (<-c.output).(models.EquityQuote) // the quote sequence directly follows
+	assert.True(t, ok)
+
+	// the trades strictly inside the gap are backfilled
+	select {
+	case b := <-c.backfills:
+		assert.Equal(t, "T", b.eventType)
+		assert.Len(t, b.msgs, 2)
+		trade := b.msgs[0].(models.EquityTrade)
+		assert.True(t, trade.Backfilled)
+		assert.Equal(t, "AAPL", trade.Symbol)
+		assert.Equal(t, int64(5), trade.SequenceNumber)
+		assert.Equal(t, int32(4), trade.Exchange)
+		assert.Equal(t, int64(2000), trade.Timestamp)
+		assert.Equal(t, int64(8), b.msgs[1].(models.EquityTrade).SequenceNumber)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the backfill")
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	assert.Equal(t, []Gap{gap}, b.gaps)
+}
+
+// slowBackfiller returns one trade per request after a delay and tracks how many requests run at the same time.
+type slowBackfiller struct {
+	mtx      sync.Mutex
+	active   int
+	peak     int
+	requests int
+}
+
+func (b *slowBackfiller) Trades(_ context.Context, gap Gap) ([]models.EquityTrade, error) {
+	b.mtx.Lock()
+	b.active++
+	b.requests++
+	b.peak = max(b.peak, b.active)
+	b.mtx.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	b.mtx.Lock()
+	b.active--
+	b.mtx.Unlock()
+	return []models.EquityTrade{{Symbol: gap.Symbol, SequenceNumber: 2}}, nil
+}
+
+func (b *slowBackfiller) Quotes(context.Context, Gap) ([]models.EquityQuote, error) {
+	return nil, nil
+}
+
+func TestGapBackfillIsBounded(t *testing.T) {
+	b := &slowBackfiller{}
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Backfill: b})
+	assert.Nil(t, err)
+	c.startBackfill()
+	defer c.ptomb.Kill(nil)
+
+	// a reconnect on a wildcard feed opens a gap for every symbol at once
+	const symbols = 50
+	route := func(seq int) {
+		var msgs []json.RawMessage
+		for i := 0; i < symbols; i++ {
+			msgs = append(msgs, json.RawMessage(fmt.Sprintf(`{"ev":"T","sym":"S%d","q":%d,"t":1000}`, i, seq)))
+		}
+		assert.Nil(t, c.route(msgs))
+	}
+	route(1)
+	c.gaps.reconnected()
+	route(3)
+
+	for i := 0; i < symbols; i++ {
+		select {
+		case res := <-c.backfills:
+			assert.Len(t, res.msgs, 1)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the backfills")
+		}
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	assert.Equal(t, symbols, b.requests)
+	assert.LessOrEqual(t, b.peak, backfillWorkers)
+}
+
+func TestBackfillQueue(t *testing.T) {
+	q := newBackfillQueue()
+	gap := func(last, next int64) Gap {
+		return Gap{EventType: "T", Symbol: "AAPL", LastSequence: last, NextSequence: next, From: time.UnixMilli(last), To: time.UnixMilli(next)}
+	}
+
+	// overlapping gaps of a stream are merged, others are queued separately
+	assert.True(t, q.push(gap(2, 10)))
+	assert.True(t, q.push(gap(5, 12)))
+	assert.True(t, q.push(gap(12, 20)))
+	assert.True(t, q.push(Gap{EventType: "Q", Symbol: "AAPL", LastSequence: 3, NextSequence: 9}))
+
+	first, ok := q.pop()
+	assert.True(t, ok)
+	assert.Equal(t, gap(2, 12), first)
+	second, _ := q.pop()
+	assert.Equal(t, gap(12, 20), second)
+	third, _ := q.pop()
+	assert.Equal(t, "Q", third.EventType)
+	_, ok = q.pop()
+	assert.False(t, ok)
+}
@@ -0,0 +1,125 @@
+package polygonws
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// handlers holds the typed callbacks registered on a client keyed by the model type they receive.
+type handlers struct {
+	mtx   sync.RWMutex
+	funcs map[reflect.Type][]func(any)
+}
+
+func (h *handlers) add(typ reflect.Type, fn func(any)) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if h.funcs == nil {
+		h.funcs = make(map[reflect.Type][]func(any))
+	}
+	h.funcs[typ] = append(h.funcs[typ], fn)
+}
+
+// dispatch calls every handler registered for the type of msg and reports whether there was one.
+func (h *handlers) dispatch(msg any) bool {
+	h.mtx.RLock()
+	funcs := h.funcs[reflect.TypeOf(msg)]
+	h.mtx.RUnlock()
+
+	for _, fn := range funcs {
+		fn(msg)
+	}
+	return len(funcs) > 0
+}
+
+func on[T any](c *Client, fn func(T)) {
+	c.handlers.add(reflect.TypeOf(*new(T)), func(msg any) { fn(msg.(T)) })
+}
+
+// emit checks a decoded message for sequence gaps and delivers it.
+func (c *Client) emit(eventType string, msg any) {
+	if c.gaps != nil {
+		c.detectGap(eventType, msg)
+	}
+	c.deliver(eventType, msg)
+}
+
+// deliver passes a message to its handlers, or pushes it to the output channel if no handler claims it.
+func (c *Client) deliver(eventType string, msg any) {
+	if c.handlers.dispatch(msg) {
+		return
+	}
+	c.out.push(eventType, msg)
+}
+
+// OnAgg registers a handler for equity, options, and index aggregates (topics like StocksSecAggs and
+// OptionsMinAggs), as well as forex and crypto aggregates from the launchpad feed.
+//
+// Handlers are called from the client's processing goroutine in the order they were registered, so they should return
+// quickly. Messages of a type that has at least one handler aren't pushed to Output. Handlers are not called if the
+// client is configured with RawData.
+func (c *Client) OnAgg(fn func(models.EquityAgg)) {
+	on(c, fn)
+}
+
+// OnCurrencyAgg registers a handler for forex and crypto aggregates. See OnAgg for how handlers are called.
+func (c *Client) OnCurrencyAgg(fn func(models.CurrencyAgg)) {
+	on(c, fn)
+}
+
+// OnTrade registers a handler for equity and options trades. See OnAgg for how handlers are called.
+func (c *Client) OnTrade(fn func(models.EquityTrade)) {
+	on(c, fn)
+}
+
+// OnCryptoTrade registers a handler for crypto trades. See OnAgg for how handlers are called.
+func (c *Client) OnCryptoTrade(fn func(models.CryptoTrade)) {
+	on(c, fn)
+}
+
+// OnQuote registers a handler for equity and options quotes. See OnAgg for how handlers are called.
+func (c *Client) OnQuote(fn func(models.EquityQuote)) {
+	on(c, fn)
+}
+
+// OnForexQuote registers a handler for forex quotes. See OnAgg for how handlers are called.
+func (c *Client) OnForexQuote(fn func(models.ForexQuote)) {
+	on(c, fn)
+}
+
+// OnCryptoQuote registers a handler for crypto quotes. See OnAgg for how handlers are called.
+func (c *Client) OnCryptoQuote(fn func(models.CryptoQuote)) {
+	on(c, fn)
+}
+
+// OnImbalance registers a handler for net order imbalances. See OnAgg for how handlers are called.
+func (c *Client) OnImbalance(fn func(models.Imbalance)) {
+	on(c, fn)
+}
+
+// OnLULD registers a handler for limit up limit down bands. See OnAgg for how handlers are called.
+func (c *Client) OnLULD(fn func(models.LimitUpLimitDown)) {
+	on(c, fn)
+}
+
+// OnLevel2Book registers a handler for crypto level 2 book updates. See OnAgg for how handlers are called.
+func (c *Client) OnLevel2Book(fn func(models.Level2Book)) {
+	on(c, fn)
+}
+
+// OnIndexValue registers a handler for index values. See OnAgg for how handlers are called.
+func (c *Client) OnIndexValue(fn func(models.IndexValue)) {
+	on(c, fn)
+}
+
+// OnLaunchpadValue registers a handler for launchpad values. See OnAgg for how handlers are called.
+func (c *Client) OnLaunchpadValue(fn func(models.LaunchpadValue)) {
+	on(c, fn)
+}
+
+// OnFMV registers a handler for fair market values. See OnAgg for how handlers are called.
+func (c *Client) OnFMV(fn func(models.FairMarketValue)) {
+	on(c, fn)
+}
@@ -0,0 +1,42 @@
+package polygonws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlers(t *testing.T) {
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+
+	var trades []models.EquityTrade
+	c.OnTrade(func(trade models.EquityTrade) { trades = append(trades, trade) })
+	var fmvs int
+	c.OnFMV(func(models.FairMarketValue) { fmvs++ })
+	c.OnFMV(func(models.FairMarketValue) { fmvs++ })
+
+	var msgs []json.RawMessage
+	err = json.Unmarshal([]byte(`[
+		{"ev":"T","sym":"AAPL","p":180.5,"s":100},
+		{"ev":"Q","sym":"AAPL","bp":180.4,"ap":180.6},
+		{"ev":"FMV","sym":"AAPL","fmv":180.51},
+		{"ev":"T","sym":"MSFT","p":370.1,"s":10}
+	]`), &msgs)
+	assert.Nil(t, err)
+	assert.Nil(t, c.route(msgs))
+
+	// claimed messages go to their handlers in order
+	assert.Len(t, trades, 2)
+	assert.Equal(t, "AAPL", trades[0].Symbol)
+	assert.Equal(t, 370.1, trades[1].Price)
+	assert.Equal(t, 2, fmvs)
+
+	// messages without a handler still reach the output channel
+	assert.Len(t, c.output, 1)
+	quote, ok := (<-c.Output()).(models.EquityQuote)
+	assert.True(t, ok)
+	assert.Equal(t, 180.6, quote.AskPrice)
+}
@@ -0,0 +1,33 @@
+// Package message inspects raw WebSocket messages without decoding them into models.
+package message
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// EventSymbol returns the event type and ticker symbol of a raw data message. The symbol is sent under different keys
+// depending on the event type, and is empty for messages without one (e.g. status messages).
+func EventSymbol(msg json.RawMessage) (string, string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(msg, &fields); err != nil {
+		return "", "", err
+	}
+
+	var ev string
+	if err := json.Unmarshal(fields["ev"], &ev); err != nil || ev == "" {
+		return "", "", errors.New("missing event type")
+	}
+
+	keys := []string{"sym", "pair", "T"}
+	if ev == "C" {
+		keys = []string{"p"}
+	}
+	for _, key := range keys {
+		var sym string
+		if err := json.Unmarshal(fields[key], &sym); err == nil && sym != "" {
+			return ev, sym, nil
+		}
+	}
+	return ev, "", nil
+}
@@ -0,0 +1,304 @@
+package polygonws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Source identifies the connection of a Manager that a message was received on.
+type Source struct {
+	Market Market
+	Feed   Feed
+}
+
+// Message is a message received by a Manager, tagged with the connection it came from.
+type Message struct {
+	Source
+	Data any
+}
+
+// ConnectionHealth is the state of one of a Manager's connections.
+type ConnectionHealth struct {
+	Source
+
+	// State is the connection state of the client.
+	State State
+
+	// Connected reports whether the client is authenticated.
+	Connected bool
+
+	// Reconnects is the number of successful automatic reconnects.
+	Reconnects int
+
+	// LastError is the most recent reconnect or fatal error, if any.
+	LastError error
+
+	// Dropped is the number of messages discarded by the overflow policy of the connection.
+	Dropped uint64
+}
+
+// Health is the aggregate state of a Manager's connections.
+type Health struct {
+	Connections []ConnectionHealth
+}
+
+// Healthy reports whether every connection is connected.
+func (h Health) Healthy() bool {
+	for _, c := range h.Connections {
+		if !c.Connected {
+			return false
+		}
+	}
+	return len(h.Connections) > 0
+}
+
+// Manager owns a set of clients across markets and feeds. Subscriptions are routed to the client whose market supports
+// the topic, and the output of every client is merged into a single stream of messages tagged with their source. When
+// several feeds of a market are used (e.g. real-time and delayed stocks), Route picks the client of a feed.
+type Manager struct {
+	conns  []*managedConn
+	output chan Message
+	err    chan error
+	done   chan struct{}
+
+	closeOnce sync.Once
+}
+
+type managedConn struct {
+	Source
+	client *Client
+
+	mtx    sync.Mutex
+	health ConnectionHealth
+}
+
+// NewManager creates a client for every config. Each market and feed pair can only be used once. The
+// ReconnectCallback of a config is still called for its connection.
+func NewManager(configs ...Config) (*Manager, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("at least one connection is required")
+	}
+
+	m := &Manager{
+		output: make(chan Message, defaultOutputBufferSize),
+		err:    make(chan error, len(configs)),
+		done:   make(chan struct{}),
+	}
+
+	seen := make(map[Source]bool)
+	for _, config := range configs {
+		src := Source{Market: config.Market, Feed: config.Feed}
+		if seen[src] {
+			return nil, fmt.Errorf("duplicate connection for market '%v' and feed '%v'", src.Market, src.Feed)
+		}
+		seen[src] = true
+
+		mc := &managedConn{Source: src}
+		mc.health.Source = src
+		callback := config.ReconnectCallback
+		config.ReconnectCallback = func(err error) {
+			mc.reconnected(err)
+			if callback != nil {
+				callback(err)
+			}
+		}
+
+		c, err := New(config)
+		if err != nil {
+			return nil, err
+		}
+		mc.client = c
+		m.conns = append(m.conns, mc)
+	}
+
+	var wg sync.WaitGroup
+	for _, mc := range m.conns {
+		wg.Add(1)
+		go func(mc *managedConn) {
+			defer wg.Done()
+			m.forward(mc)
+		}(mc)
+		go m.forwardErrors(mc)
+	}
+	go func() {
+		wg.Wait()
+		close(m.output)
+	}()
+
+	return m, nil
+}
+
+// Connect connects every client. It stops at the first client that fails to connect and closes the manager, so that
+// the clients that already connected don't keep running.
+func (m *Manager) Connect() error {
+	for _, mc := range m.conns {
+		if err := mc.client.Connect(); err != nil {
+			mc.failed(err)
+			m.Close()
+			return fmt.Errorf("failed to connect to market '%v' on feed '%v': %w", mc.Market, mc.Feed, err)
+		}
+	}
+	return nil
+}
+
+// Route returns the client of a feed that a subscription to the topic is sent to. An empty feed matches every feed.
+// Clients with a known market are preferred over clients with a custom market, which are assumed to support every
+// topic. It's an error if more than one client matches.
+func (m *Manager) Route(feed Feed, topic Topic) (*Client, error) {
+	var known, custom []*managedConn
+	for _, mc := range m.conns {
+		switch {
+		case feed != "" && mc.Feed != feed:
+		case !mc.Market.known():
+			custom = append(custom, mc)
+		case mc.Market.supports(topic):
+			known = append(known, mc)
+		}
+	}
+
+	matches := known
+	if len(matches) == 0 {
+		matches = custom
+	}
+	switch len(matches) {
+	case 0:
+		if feed != "" {
+			return nil, fmt.Errorf("no connection on feed '%v' supports topic '%v'", feed, topic.prefix())
+		}
+		return nil, fmt.Errorf("no connection supports topic '%v'", topic.prefix())
+	case 1:
+		return matches[0].client, nil
+	}
+	return nil, fmt.Errorf("topic '%v' is supported by feeds '%v' and '%v': use Route to pick one", topic.prefix(), matches[0].Feed, matches[1].Feed)
+}
+
+// Subscribe routes a subscription to the only client that supports the topic, as Route does for every feed.
+func (m *Manager) Subscribe(topic Topic, tickers ...string) error {
+	c, err := m.Route("", topic)
+	if err != nil {
+		return err
+	}
+	return c.Subscribe(topic, tickers...)
+}
+
+// SubscribeAndWait routes a subscription like Subscribe and waits for the server to reply to every ticker.
+func (m *Manager) SubscribeAndWait(ctx context.Context, topic Topic, tickers ...string) ([]SubscriptionResult, error) {
+	c, err := m.Route("", topic)
+	if err != nil {
+		return nil, err
+	}
+	return c.SubscribeAndWait(ctx, topic, tickers...)
+}
+
+// Unsubscribe routes an unsubscription to the client that Subscribe routes the topic to.
+func (m *Manager) Unsubscribe(topic Topic, tickers ...string) error {
+	c, err := m.Route("", topic)
+	if err != nil {
+		return err
+	}
+	return c.Unsubscribe(topic, tickers...)
+}
+
+// Client returns the client of a market and feed, e.g. to register typed handlers on it. Messages claimed by a
+// handler aren't pushed to the merged output.
+func (m *Manager) Client(market Market, feed Feed) (*Client, bool) {
+	for _, mc := range m.conns {
+		if mc.Market == market && mc.Feed == feed {
+			return mc.client, true
+		}
+	}
+	return nil, false
+}
+
+// Output returns the merged output of every client. It's closed once every client is closed.
+func (m *Manager) Output() <-chan Message {
+	return m.output
+}
+
+// Error returns the fatal errors of every client. A client that hits a fatal error is closed, but the others keep
+// running.
+func (m *Manager) Error() <-chan error {
+	return m.err
+}
+
+// Health returns the state of every connection in the order they were configured.
+func (m *Manager) Health() Health {
+	var h Health
+	for _, mc := range m.conns {
+		mc.mtx.Lock()
+		ch := mc.health
+		mc.mtx.Unlock()
+		ch.State = mc.client.State()
+		ch.Connected = ch.State == Connected
+		ch.Dropped = sum(mc.client.Dropped())
+		h.Connections = append(h.Connections, ch)
+	}
+	return h
+}
+
+// Close closes every client.
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+		for _, mc := range m.conns {
+			mc.client.closeForManager()
+		}
+	})
+}
+
+func (m *Manager) forward(mc *managedConn) {
+	for out := range mc.client.Output() {
+		m.output <- Message{Source: mc.Source, Data: out}
+	}
+}
+
+func (m *Manager) forwardErrors(mc *managedConn) {
+	for {
+		select {
+		case <-m.done:
+			return
+		case err := <-mc.client.Error():
+			mc.failed(err)
+			err = fmt.Errorf("market '%v' on feed '%v': %w", mc.Market, mc.Feed, err)
+			select {
+			case m.err <- err:
+			case <-m.done:
+				return
+			}
+		}
+	}
+}
+
+func (mc *managedConn) reconnected(err error) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	if err != nil {
+		mc.health.LastError = err
+		return
+	}
+	mc.health.Reconnects++
+}
+
+func (mc *managedConn) failed(err error) {
+	mc.mtx.Lock()
+	defer mc.mtx.Unlock()
+	mc.health.LastError = err
+}
+
+func (m Market) known() bool {
+	switch m {
+	case Stocks, Options, Forex, Crypto, Indices:
+		return true
+	}
+	return false
+}
+
+func sum(counts map[string]uint64) uint64 {
+	var n uint64
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
@@ -0,0 +1,121 @@
+package polygonws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	polygonws "github.com/polygon-io/client-go/websocket"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/polygon-io/client-go/websocket/polygonwstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	stocks := polygonwstest.NewServer("key")
+	defer stocks.Close()
+	crypto := polygonwstest.NewServer("key")
+	defer crypto.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	m, err := polygonws.NewManager(
+		polygonws.Config{APIKey: "key", Feed: polygonws.Feed(stocks.URL()), Market: polygonws.Stocks, WaitForAuth: true},
+		polygonws.Config{APIKey: "key", Feed: polygonws.Feed(crypto.URL()), Market: polygonws.Crypto, WaitForAuth: true},
+	)
+	assert.Nil(t, err)
+	assert.False(t, m.Health().Healthy())
+
+	// subscriptions go to the connection that supports the topic
+	assert.Nil(t, m.Subscribe(polygonws.StocksTrades, "AAPL"))
+	assert.Nil(t, m.Subscribe(polygonws.CryptoTrades, "BTC-USD"))
+	assert.NotNil(t, m.Subscribe(polygonws.ForexQuotes, "EUR/USD"))
+
+	assert.Nil(t, m.Connect())
+	assert.True(t, m.Health().Healthy())
+	assert.Nil(t, stocks.WaitForSubscription(ctx, "T.AAPL"))
+	assert.Nil(t, crypto.WaitForSubscription(ctx, "XT.BTC-USD"))
+	assert.Equal(t, []string{"T.AAPL"}, stocks.Subscriptions())
+	assert.Equal(t, []string{"XT.BTC-USD"}, crypto.Subscriptions())
+
+	// output is merged and tagged with its source
+	assert.Nil(t, stocks.Generate("T", "AAPL", 1))
+	assert.Nil(t, crypto.Generate("XT", "BTC-USD", 1))
+	got := make(map[polygonws.Market]any)
+	for len(got) < 2 {
+		select {
+		case msg := <-m.Output():
+			got[msg.Market] = msg.Data
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for messages")
+		}
+	}
+	assert.Equal(t, "AAPL", got[polygonws.Stocks].(models.EquityTrade).Symbol)
+	assert.Equal(t, "BTC-USD", got[polygonws.Crypto].(models.CryptoTrade).Pair)
+
+	c, ok := m.Client(polygonws.Crypto, polygonws.Feed(crypto.URL()))
+	assert.True(t, ok)
+	assert.NotNil(t, c)
+
+	health := m.Health()
+	assert.Len(t, health.Connections, 2)
+	assert.Equal(t, polygonws.Stocks, health.Connections[0].Market)
+	assert.True(t, health.Connections[0].Connected)
+
+	m.Close()
+	for range m.Output() {
+	}
+	assert.False(t, m.Health().Healthy())
+
+	// every market and feed pair can only be used once
+	_, err = polygonws.NewManager(
+		polygonws.Config{APIKey: "key", Feed: polygonws.RealTime, Market: polygonws.Stocks},
+		polygonws.Config{APIKey: "key", Feed: polygonws.RealTime, Market: polygonws.Stocks},
+	)
+	assert.NotNil(t, err)
+}
+
+func TestManagerRoute(t *testing.T) {
+	realtime := polygonwstest.NewServer("key")
+	defer realtime.Close()
+	delayed := polygonwstest.NewServer("key")
+	defer delayed.Close()
+
+	m, err := polygonws.NewManager(
+		polygonws.Config{APIKey: "key", Feed: polygonws.Feed(realtime.URL()), Market: polygonws.Stocks},
+		polygonws.Config{APIKey: "key", Feed: polygonws.Feed(delayed.URL()), Market: polygonws.Stocks},
+	)
+	assert.Nil(t, err)
+	defer m.Close()
+
+	// both feeds support the topic, so a feed has to be picked
+	assert.NotNil(t, m.Subscribe(polygonws.StocksTrades, "AAPL"))
+	c, err := m.Route(polygonws.Feed(delayed.URL()), polygonws.StocksTrades)
+	assert.Nil(t, err)
+	expect, _ := m.Client(polygonws.Stocks, polygonws.Feed(delayed.URL()))
+	assert.Same(t, expect, c)
+	_, err = m.Route(polygonws.Feed(delayed.URL()), polygonws.CryptoTrades)
+	assert.NotNil(t, err)
+}
+
+func TestManagerConnectFailed(t *testing.T) {
+	s := polygonwstest.NewServer("key")
+	defer s.Close()
+	down := polygonwstest.NewServer("key")
+	down.Close()
+
+	var retries uint64
+	m, err := polygonws.NewManager(
+		polygonws.Config{APIKey: "key", Feed: polygonws.Feed(s.URL()), Market: polygonws.Stocks, WaitForAuth: true},
+		polygonws.Config{APIKey: "key", Feed: polygonws.Feed(down.URL()), Market: polygonws.Crypto, MaxRetries: &retries},
+	)
+	assert.Nil(t, err)
+	assert.NotNil(t, m.Connect())
+
+	// the client that connected is closed with the rest
+	c, _ := m.Client(polygonws.Stocks, polygonws.Feed(s.URL()))
+	assert.Equal(t, polygonws.Closed, c.State())
+	for range m.Output() {
+	}
+}
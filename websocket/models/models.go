@@ -146,6 +146,10 @@ type EquityTrade struct {
 	// The TRF (Trade Reporting Facility) Timestamp in Unix MS.
 	// This is the timestamp of when the trade reporting facility received this trade.
 	TradeReportingFacilityTimestamp int64 `json:"trft,omitempty"`
+
+	// Backfilled is set by the client on trades fetched from the REST API to fill a sequence gap. It's never sent by the
+	// server.
+	Backfilled bool `json:"backfilled,omitempty"`
 }
 
 // CryptoTrade is a trade for a crypto pair.
@@ -221,6 +225,10 @@ type EquityQuote struct {
 	// The sequence number represents the sequence in which message events happened. These are increasing and unique per
 	// ticker symbol, but will not always be sequential (e.g., 1, 2, 6, 9, 10, 11).
 	SequenceNumber int64 `json:"q,omitempty"`
+
+	// Backfilled is set by the client on quotes fetched from the REST API to fill a sequence gap. It's never sent by the
+	// server.
+	Backfilled bool `json:"backfilled,omitempty"`
 }
 
 // ForexQuote is a quote for a forex currency pair.
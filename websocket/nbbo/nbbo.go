@@ -0,0 +1,239 @@
+// Package nbbo builds a consolidated best bid and offer from per-exchange quotes.
+//
+// A Builder keeps the latest bid and ask of every exchange per symbol and derives the national best bid and offer
+// (NBBO) from them. It can be fed live with the quotes of the WebSocket client, or with historical quotes from the
+// REST API for backtests:
+//
+//	b := nbbo.New(nbbo.Config{OnChange: func(n nbbo.NBBO) {
+//		if n.Crossed {
+//			log.Printf("%v is crossed: %v > %v", n.Symbol, n.Bid.Price, n.Ask.Price)
+//		}
+//	}})
+//	c.OnQuote(b.OnEquityQuote)
+//
+//	changes := nbbo.New(nbbo.Config{}).Replay("AAPL", quotes)
+//
+// Sizes are used as reported, so they're round lots for stock quotes from the WebSocket API.
+package nbbo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	restmodels "github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// Quote is a bid and ask from one or two exchanges. A price of zero means the exchange has no quote on that side.
+type Quote struct {
+	Symbol      string
+	BidExchange int32
+	BidPrice    float64
+	BidSize     float64
+	AskExchange int32
+	AskPrice    float64
+	AskSize     float64
+	Timestamp   time.Time
+}
+
+// FromEquityQuote converts a stock quote from the WebSocket API.
+func FromEquityQuote(q models.EquityQuote) Quote {
+	return Quote{
+		Symbol:      q.Symbol,
+		BidExchange: q.BidExchangeID,
+		BidPrice:    q.BidPrice,
+		BidSize:     float64(q.BidSize),
+		AskExchange: q.AskExchangeID,
+		AskPrice:    q.AskPrice,
+		AskSize:     float64(q.AskSize),
+		Timestamp:   time.UnixMilli(q.Timestamp),
+	}
+}
+
+// FromCryptoQuote converts a crypto quote from the WebSocket API. Both sides are quoted by the same exchange.
+func FromCryptoQuote(q models.CryptoQuote) Quote {
+	return Quote{
+		Symbol:      q.Pair,
+		BidExchange: q.ExchangeID,
+		BidPrice:    q.BidPrice,
+		BidSize:     q.BidSize,
+		AskExchange: q.ExchangeID,
+		AskPrice:    q.AskPrice,
+		AskSize:     q.AskSize,
+		Timestamp:   time.UnixMilli(q.Timestamp),
+	}
+}
+
+// FromREST converts a historical quote from the REST API, which doesn't include the symbol.
+func FromREST(symbol string, q restmodels.Quote) Quote {
+	return Quote{
+		Symbol:      symbol,
+		BidExchange: int32(q.BidExchange),
+		BidPrice:    q.BidPrice,
+		BidSize:     q.BidSize,
+		AskExchange: int32(q.AskExchange),
+		AskPrice:    q.AskPrice,
+		AskSize:     q.AskSize,
+		Timestamp:   time.Time(q.SipTimestamp),
+	}
+}
+
+// Best is one side of the NBBO.
+type Best struct {
+	Price float64
+
+	// Size is the total size of every exchange quoting the price.
+	Size float64
+
+	// Exchanges are the sorted IDs of the exchanges quoting the price.
+	Exchanges []int32
+}
+
+// NBBO is the consolidated top of book of a symbol.
+type NBBO struct {
+	Symbol string
+
+	// Bid is the highest bid and Ask the lowest ask across exchanges. A side without any quotes has a price of zero.
+	Bid Best
+	Ask Best
+
+	// Locked is set when the best bid equals the best ask, and Crossed when it's higher.
+	Locked  bool
+	Crossed bool
+
+	// Timestamp is the time of the quote that produced this NBBO.
+	Timestamp time.Time
+}
+
+// Config is a set of builder options.
+type Config struct {
+	// OnChange is called with the new NBBO whenever the price or size of either side changes. It's called while the
+	// builder is locked, so it must not call the builder.
+	OnChange func(NBBO)
+}
+
+// Builder maintains the NBBO of every symbol. It's safe for concurrent use.
+type Builder struct {
+	onChange func(NBBO)
+
+	mtx     sync.Mutex
+	symbols map[string]*symbol
+}
+
+type side struct {
+	price float64
+	size  float64
+}
+
+type symbol struct {
+	bids map[int32]side
+	asks map[int32]side
+	nbbo NBBO
+}
+
+// New creates a builder.
+func New(config Config) *Builder {
+	return &Builder{onChange: config.OnChange, symbols: make(map[string]*symbol)}
+}
+
+// OnEquityQuote applies a stock quote. It has the signature of an OnQuote handler.
+func (b *Builder) OnEquityQuote(q models.EquityQuote) {
+	b.Update(FromEquityQuote(q))
+}
+
+// OnCryptoQuote applies a crypto quote. It has the signature of an OnCryptoQuote handler.
+func (b *Builder) OnCryptoQuote(q models.CryptoQuote) {
+	b.Update(FromCryptoQuote(q))
+}
+
+// Update replaces the bid of the bid exchange and the ask of the ask exchange of a quote. It returns the NBBO of the
+// symbol and whether it changed.
+func (b *Builder) Update(q Quote) (NBBO, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	s, ok := b.symbols[q.Symbol]
+	if !ok {
+		s = &symbol{bids: make(map[int32]side), asks: make(map[int32]side), nbbo: NBBO{Symbol: q.Symbol}}
+		b.symbols[q.Symbol] = s
+	}
+	set(s.bids, q.BidExchange, q.BidPrice, q.BidSize)
+	set(s.asks, q.AskExchange, q.AskPrice, q.AskSize)
+
+	n := NBBO{
+		Symbol:    q.Symbol,
+		Bid:       best(s.bids, func(a, b float64) bool { return a > b }),
+		Ask:       best(s.asks, func(a, b float64) bool { return a < b }),
+		Timestamp: q.Timestamp,
+	}
+	if n.Bid.Price > 0 && n.Ask.Price > 0 {
+		n.Locked = n.Bid.Price == n.Ask.Price
+		n.Crossed = n.Bid.Price > n.Ask.Price
+	}
+
+	changed := n.Bid.Price != s.nbbo.Bid.Price || n.Bid.Size != s.nbbo.Bid.Size ||
+		n.Ask.Price != s.nbbo.Ask.Price || n.Ask.Size != s.nbbo.Ask.Size
+	if !changed {
+		return s.nbbo, false
+	}
+	s.nbbo = n
+	if b.onChange != nil {
+		b.onChange(n)
+	}
+	return n, true
+}
+
+// Replay applies historical quotes of a symbol in order and returns every NBBO change.
+func (b *Builder) Replay(symbol string, quotes []restmodels.Quote) []NBBO {
+	var changes []NBBO
+	for _, q := range quotes {
+		if n, ok := b.Update(FromREST(symbol, q)); ok {
+			changes = append(changes, n)
+		}
+	}
+	return changes
+}
+
+// NBBO returns the current NBBO of a symbol.
+func (b *Builder) NBBO(symbol string) (NBBO, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	s, ok := b.symbols[symbol]
+	if !ok {
+		return NBBO{}, false
+	}
+	return s.nbbo, true
+}
+
+func set(sides map[int32]side, exchange int32, price, size float64) {
+	if price <= 0 {
+		delete(sides, exchange)
+		return
+	}
+	sides[exchange] = side{price: price, size: size}
+}
+
+// best aggregates the exchanges quoting the best price of a side, where better reports whether a price improves on
+// another. Exchanges are visited in order so that sizes are always summed the same way.
+func best(sides map[int32]side, better func(a, b float64) bool) Best {
+	exchanges := make([]int32, 0, len(sides))
+	for x := range sides {
+		exchanges = append(exchanges, x)
+	}
+	sort.Slice(exchanges, func(i, j int) bool { return exchanges[i] < exchanges[j] })
+
+	var out Best
+	for _, x := range exchanges {
+		s := sides[x]
+		switch {
+		case out.Price == 0 || better(s.price, out.Price):
+			out = Best{Price: s.price, Size: s.size, Exchanges: []int32{x}}
+		case s.price == out.Price:
+			out.Size += s.size
+			out.Exchanges = append(out.Exchanges, x)
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package nbbo_test
+
+import (
+	"testing"
+	"time"
+
+	restmodels "github.com/polygon-io/client-go/rest/models"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/polygon-io/client-go/websocket/nbbo"
+	"github.com/stretchr/testify/assert"
+)
+
+func quote(exchange int32, bid, bidSize, ask, askSize float64) models.EquityQuote {
+	return models.EquityQuote{
+		Symbol:        "AAPL",
+		BidExchangeID: exchange,
+		BidPrice:      bid,
+		BidSize:       int32(bidSize),
+		AskExchangeID: exchange,
+		AskPrice:      ask,
+		AskSize:       int32(askSize),
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	var changes []nbbo.NBBO
+	b := nbbo.New(nbbo.Config{OnChange: func(n nbbo.NBBO) { changes = append(changes, n) }})
+
+	b.OnEquityQuote(quote(1, 100, 2, 101, 3))
+	b.OnEquityQuote(quote(2, 100, 1, 100.5, 4))
+	n, ok := b.NBBO("AAPL")
+	assert.True(t, ok)
+	assert.Equal(t, nbbo.Best{Price: 100, Size: 3, Exchanges: []int32{1, 2}}, n.Bid)
+	assert.Equal(t, nbbo.Best{Price: 100.5, Size: 4, Exchanges: []int32{2}}, n.Ask)
+	assert.False(t, n.Locked)
+	assert.Len(t, changes, 2)
+
+	// a quote that doesn't move the top of book isn't a change
+	b.OnEquityQuote(quote(3, 99, 5, 102, 5))
+	assert.Len(t, changes, 2)
+
+	// locked and crossed markets are flagged
+	b.OnEquityQuote(quote(3, 100.5, 1, 102, 5))
+	assert.True(t, changes[2].Locked)
+	b.OnEquityQuote(quote(3, 100.75, 1, 102, 5))
+	assert.True(t, changes[3].Crossed)
+	assert.Equal(t, []int32{3}, changes[3].Bid.Exchanges)
+
+	// an exchange without a bid drops out
+	b.OnEquityQuote(quote(3, 0, 0, 102, 5))
+	n, _ = b.NBBO("AAPL")
+	assert.Equal(t, 100.0, n.Bid.Price)
+	assert.False(t, n.Crossed)
+
+	_, ok = b.NBBO("MSFT")
+	assert.False(t, ok)
+}
+
+func TestBuilderConsolidatedQuotes(t *testing.T) {
+	b := nbbo.New(nbbo.Config{})
+
+	// the bid and ask of a quote can come from different exchanges
+	n, ok := b.Update(nbbo.FromEquityQuote(models.EquityQuote{Symbol: "AAPL", BidExchangeID: 1, BidPrice: 100, BidSize: 1, AskExchangeID: 2, AskPrice: 101, AskSize: 1}))
+	assert.True(t, ok)
+	assert.Equal(t, []int32{1}, n.Bid.Exchanges)
+	assert.Equal(t, []int32{2}, n.Ask.Exchanges)
+
+	n, _ = b.Update(nbbo.FromCryptoQuote(models.CryptoQuote{Pair: "BTC-USD", ExchangeID: 1, BidPrice: 30000, BidSize: 0.5, AskPrice: 30001, AskSize: 0.25}))
+	assert.Equal(t, "BTC-USD", n.Symbol)
+	assert.Equal(t, 0.25, n.Ask.Size)
+}
+
+func TestReplay(t *testing.T) {
+	ts := func(ms int64) restmodels.Nanos { return restmodels.Nanos(time.UnixMilli(ms)) }
+	quotes := []restmodels.Quote{
+		{BidExchange: 11, BidPrice: 100, BidSize: 100, AskExchange: 11, AskPrice: 101, AskSize: 100, SipTimestamp: ts(1)},
+		{BidExchange: 12, BidPrice: 99, BidSize: 100, AskExchange: 12, AskPrice: 102, AskSize: 100, SipTimestamp: ts(2)},
+		{BidExchange: 12, BidPrice: 101, BidSize: 200, AskExchange: 12, AskPrice: 102, AskSize: 100, SipTimestamp: ts(3)},
+	}
+
+	changes := nbbo.New(nbbo.Config{}).Replay("AAPL", quotes)
+	assert.Len(t, changes, 2)
+	assert.Equal(t, time.UnixMilli(3), changes[1].Timestamp)
+	assert.True(t, changes[1].Locked)
+	assert.Equal(t, "AAPL", changes[1].Symbol)
+}
@@ -22,6 +22,8 @@ const (
 	pongWait       = 10 * time.Second
 	pingPeriod     = (pongWait * 9) / 10
 	maxMessageSize = 1000000 // 1MB
+
+	outboxTickPeriod = 10 * time.Millisecond
 )
 
 // Client defines a client to the Polygon WebSocket API.
@@ -42,14 +44,28 @@ type Client struct {
 	rQueue chan json.RawMessage
 	wQueue chan json.RawMessage
 	subs   subscriptions
+	acks   *acks
 
 	rawData              bool
 	bypassRawDataRouting bool
 	output               chan any
+	out                  *outbox
+	handlers             handlers
+	gaps                 *gapTracker
+	backfiller           Backfiller
+	backfills            chan backfill
+	backfillQueue        *backfillQueue
+	recorder             *Recorder
 	err                  chan error
 
+	waitForAuth       bool
 	reconnectCallback func(error)
 	log               Logger
+
+	stateMtx  sync.Mutex
+	state     State
+	lastEvent StateEvent
+	watchers  map[chan StateEvent]struct{}
 }
 
 // New creates a client for the Polygon WebSocket API.
@@ -66,14 +82,25 @@ func New(config Config) (*Client, error) {
 		rQueue:               make(chan json.RawMessage, 10000),
 		wQueue:               make(chan json.RawMessage, 1000),
 		subs:                 make(subscriptions),
+		acks:                 newAcks(!(config.RawData && config.BypassRawDataRouting)),
 		rawData:              config.RawData,
 		bypassRawDataRouting: config.BypassRawDataRouting,
-		output:               make(chan any, 100000),
-		err:                  make(chan error),
+		err:                  make(chan error, 1),
 		log:                  config.Log,
 		reconnectCallback:    config.ReconnectCallback,
+		waitForAuth:          config.WaitForAuth,
+		recorder:             config.Recorder,
 	}
 
+	c.out = newOutbox(config)
+	if config.DetectGaps || config.Backfill != nil {
+		c.gaps = newGapTracker()
+		c.backfiller = config.Backfill
+		c.backfills = make(chan backfill)
+		c.backfillQueue = newBackfillQueue()
+	}
+	c.output = c.out.ch
+
 	uri, err := url.Parse(string(c.feed))
 	if err != nil {
 		return nil, fmt.Errorf("invalid data feed format: %v", err)
@@ -90,19 +117,42 @@ func New(config Config) (*Client, error) {
 
 // Connect dials the WebSocket server and starts the read/write and process threads.
 // If any subscription messages are pushed before connecting, it will also send those
-// to the server.
+// to the server. If WaitForAuth is set, it also waits for the server to accept the API key.
 func (c *Client) Connect() error {
+	if !c.waitForAuth {
+		return c.dial()
+	}
+
+	events, stop := c.Events()
+	defer stop()
+	if err := c.dial(); err != nil {
+		return err
+	}
+	if err := c.awaitAuth(events); err != nil {
+		// don't leave the connection retrying in the background, or a second call would dial again
+		c.Close()
+		return err
+	}
+	return nil
+}
+
+func (c *Client) dial() error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
 	if c.conn != nil {
 		return nil
 	}
+	if c.shouldClose {
+		return errClientEnded
+	}
 
+	c.setState(Connecting, ReasonNone, nil)
 	notify := func(err error, _ time.Duration) {
 		c.log.Errorf(err.Error())
 	}
 	if err := backoff.RetryNotify(c.connect(false), c.backoff, notify); err != nil {
+		c.setState(Disconnected, ReasonDialFailed, err)
 		return err
 	}
 
@@ -110,13 +160,19 @@ func (c *Client) Connect() error {
 }
 
 // Subscribe sends a subscription message for a topic and set of tickers. If no
-// tickers are passed, it will subscribe to all tickers for a given topic.
+// tickers are passed, it will subscribe to all tickers for a given topic. Use
+// SubscribeAndWait to find out whether the server accepted the subscription.
 func (c *Client) Subscribe(topic Topic, tickers ...string) error {
+	_, err := c.subscribe(topic, false, tickers...)
+	return err
+}
+
+func (c *Client) subscribe(topic Topic, wait bool, tickers ...string) (*ack, error) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
 	if !c.market.supports(topic) {
-		return fmt.Errorf("topic '%v' not supported for market '%v'", topic.prefix(), c.market)
+		return nil, fmt.Errorf("topic '%v' not supported for market '%v'", topic.prefix(), c.market)
 	}
 
 	if len(tickers) == 0 || slices.Contains(tickers, "*") {
@@ -125,13 +181,15 @@ func (c *Client) Subscribe(topic Topic, tickers ...string) error {
 
 	subscribe, err := getSub(models.Subscribe, topic, tickers...)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	c.pruneRejected()
 	c.subs.add(topic, tickers...)
+	a := c.acks.expect(models.Subscribe, topic, tickers, wait)
 	c.wQueue <- subscribe
 
-	return nil
+	return a, nil
 }
 
 // Unsubscribe sends a message to unsubscribe from a topic and set of tickers. If no
@@ -153,23 +211,36 @@ func (c *Client) Unsubscribe(topic Topic, tickers ...string) error {
 		return err
 	}
 
+	c.pruneRejected()
 	c.subs.delete(topic, tickers...)
+	c.acks.expect(models.Unsubscribe, topic, tickers, false)
 	c.wQueue <- unsubscribe
 
 	return nil
 }
 
-// Output returns the output queue.
+// Output returns the output queue. Messages claimed by a handler registered with one of the On methods (e.g. OnTrade)
+// aren't pushed to it.
 func (c *Client) Output() <-chan any {
 	return c.output
 }
 
 // Error returns an error channel. If the client hits a fatal error (e.g. auth failed),
-// it will push an error to this channel and close the connection.
+// it will push an error to this channel and close the connection. Only the first fatal error is kept until it's read.
 func (c *Client) Error() <-chan error {
 	return c.err
 }
 
+// fail pushes a fatal error without blocking. The client closes after a fatal error, so a later one is dropped if the
+// first hasn't been read.
+func (c *Client) fail(err error) {
+	select {
+	case c.err <- err:
+	default:
+		c.log.Debugf("dropped fatal error: %v", err)
+	}
+}
+
 // Close attempts to gracefully close the connection to the server.
 func (c *Client) Close() {
 	c.mtx.Lock()
@@ -177,6 +248,23 @@ func (c *Client) Close() {
 	c.close(false)
 }
 
+// closeForManager closes a client of a Manager. Unlike Close, it also closes the output of a client that never
+// connected, since the merged output of the manager is only closed once every client output is.
+func (c *Client) closeForManager() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.conn != nil {
+		c.close(false)
+		return
+	}
+	if !c.shouldClose {
+		c.shouldClose = true
+		c.closeOutput()
+		c.setState(Closed, ReasonNone, nil)
+	}
+}
+
 func newConn(uri string) (*websocket.Conn, error) {
 	conn, res, err := websocket.DefaultDialer.Dial(uri, nil)
 	if err != nil {
@@ -204,6 +292,7 @@ func (c *Client) connect(reconnect bool) func() error {
 			return err
 		}
 		c.conn = conn
+		c.setState(Authenticating, ReasonNone, nil)
 
 		// reset write queue and push auth message
 		c.wQueue = make(chan json.RawMessage, 1000)
@@ -216,7 +305,12 @@ func (c *Client) connect(reconnect bool) func() error {
 		}
 		c.wQueue <- auth
 
-		// push subscription messages
+		// push subscription messages, except for the ones the server rejected
+		c.pruneRejected()
+		c.acks.reset()
+		for topic, tickers := range c.subs {
+			c.acks.expect(models.Subscribe, topic, maps.Keys(tickers), false)
+		}
 		subs := c.subs.get()
 		for _, msg := range subs {
 			c.wQueue <- msg
@@ -229,6 +323,9 @@ func (c *Client) connect(reconnect bool) func() error {
 		if !reconnect {
 			c.ptomb = tomb.Tomb{}
 			c.ptomb.Go(c.process)
+			if c.backfiller != nil {
+				c.startBackfill()
+			}
 		}
 
 		return nil
@@ -245,6 +342,8 @@ func (c *Client) reconnect() {
 
 	c.log.Debugf("unexpected disconnect: reconnecting")
 	c.close(true)
+	cause := c.rwtomb.Err()
+	c.setState(Reconnecting, disconnectReason(cause), cause)
 
 	notify := func(err error, _ time.Duration) {
 		c.log.Errorf(err.Error())
@@ -256,9 +355,14 @@ func (c *Client) reconnect() {
 	if err != nil {
 		err = fmt.Errorf("error reconnecting: %w: closing connection", err)
 		c.log.Errorf(err.Error())
-		c.close(false)
-		c.err <- err
+		c.setState(Disconnected, ReasonDialFailed, err)
+		c.fail(err)
+		// the connection is already closed, so only the process thread is left to stop
+		c.shutdown()
 	} else {
+		if c.gaps != nil {
+			c.gaps.reconnected()
+		}
 		// Callback on success.
 		if c.reconnectCallback != nil {
 			c.reconnectCallback(nil)
@@ -282,12 +386,7 @@ func (c *Client) close(reconnect bool) {
 	}
 
 	if !reconnect {
-		c.ptomb.Kill(nil)
-		if err := c.ptomb.Wait(); err != nil {
-			c.log.Errorf("process thread closed: %v", err)
-		}
-		c.shouldClose = true
-		c.closeOutput()
+		c.shutdown()
 	}
 
 	if c.conn != nil {
@@ -296,6 +395,17 @@ func (c *Client) close(reconnect bool) {
 	}
 }
 
+// shutdown stops the process thread and closes the output for good.
+func (c *Client) shutdown() {
+	c.ptomb.Kill(nil)
+	if err := c.ptomb.Wait(); err != nil {
+		c.log.Errorf("process thread closed: %v", err)
+	}
+	c.shouldClose = true
+	c.closeOutput()
+	c.setState(Closed, ReasonNone, nil)
+}
+
 func (c *Client) read() error {
 	defer func() {
 		c.log.Debugf("read thread closed")
@@ -309,12 +419,22 @@ func (c *Client) read() error {
 			_, msg, err := c.conn.ReadMessage()
 			if err != nil {
 				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
-					return nil
+					select {
+					case <-c.rwtomb.Dying():
+						return nil
+					default:
+						return fmt.Errorf("server closed the connection: %w", err)
+					}
 				} else if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
 					return fmt.Errorf("connection closed unexpectedly: %w", err)
 				}
 				return fmt.Errorf("failed to read message: %w", err)
 			}
+			if c.recorder != nil {
+				if err := c.recorder.Record(time.Now(), msg); err != nil {
+					c.log.Errorf("failed to record message: %v", err)
+				}
+			}
 			c.rQueue <- msg
 		}
 	}
@@ -337,7 +457,7 @@ func (c *Client) write() error {
 			return nil
 		case <-ticker.C:
 			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
-				return fmt.Errorf("failed to send ping message: %w", err)
+				return fmt.Errorf("%w: %w", errPingFailed, err)
 			}
 		case msg := <-c.wQueue:
 			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
@@ -351,12 +471,20 @@ func (c *Client) write() error {
 }
 
 func (c *Client) process() (err error) {
+	// conflated messages and watermarks need attention even when no new messages arrive
+	var tick <-chan time.Time
+	if c.out.ticks() {
+		ticker := time.NewTicker(outboxTickPeriod)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
 	defer func() {
 		// this client should close if it hits a fatal error (e.g. auth failed)
 		c.log.Debugf("process thread closed")
 		if err != nil {
 			go c.Close()
-			c.err <- err
+			c.fail(err)
 		}
 	}()
 
@@ -364,24 +492,37 @@ func (c *Client) process() (err error) {
 		select {
 		case <-c.ptomb.Dying():
 			return nil
-		case data := <-c.rQueue:
-			if c.rawData && c.bypassRawDataRouting {
-				c.output <- data // push raw bytes to output channel
-				continue
-			}
-
-			var msgs []json.RawMessage
-			if err := json.Unmarshal(data, &msgs); err != nil {
-				c.log.Errorf("failed to process raw messages: %v", err)
-				continue
+		case <-tick:
+			c.out.tick()
+		case b := <-c.backfills:
+			for _, msg := range b.msgs {
+				c.deliver(b.eventType, msg)
 			}
-			if err := c.route(msgs); err != nil {
+		case data := <-c.rQueue:
+			if err := c.handleFrame(data); err != nil {
 				return err
 			}
 		}
 	}
 }
 
+func (c *Client) handleFrame(data []byte) error {
+	if c.rawData && c.bypassRawDataRouting {
+		if c.State() == Authenticating {
+			c.peekAuth(data)
+		}
+		c.out.push("", data) // push raw bytes to output channel
+		return nil
+	}
+
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		c.log.Errorf("failed to process raw messages: %v", err)
+		return nil
+	}
+	return c.route(msgs)
+}
+
 func (c *Client) route(msgs []json.RawMessage) error {
 	for _, msg := range msgs {
 		var ev models.EventType
@@ -416,12 +557,16 @@ func (c *Client) handleStatus(msg json.RawMessage) error {
 		c.log.Debugf("connection successful")
 	case "auth_success":
 		c.log.Debugf("authentication successful")
+		c.advance(Authenticating, Connected, ReasonNone, nil)
 	case "auth_failed":
 		// this is a fatal error so need to close the connection
-		return errors.New("authentication failed: closing connection")
+		c.advance(Authenticating, Disconnected, ReasonAuthFailed, errAuthFailed)
+		return fmt.Errorf("%w: closing connection", errAuthFailed)
 	case "success":
+		c.acks.resolve(cm.Status, cm.Message)
 		c.log.Debugf("received a successful status message: %v", sanitize(cm.Message))
 	case "error":
+		c.acks.resolve(cm.Status, cm.Message)
 		c.log.Errorf("received an error status message: %v", sanitize(cm.Message))
 	default:
 		c.log.Infof("unknown status message '%v': %v", sanitize(cm.Status), sanitize(cm.Message))
@@ -430,9 +575,26 @@ func (c *Client) handleStatus(msg json.RawMessage) error {
 	return nil
 }
 
+// peekAuth tracks the authentication status of raw frames that bypass routing. The caller is responsible for
+// handling an auth failure.
+func (c *Client) peekAuth(data []byte) {
+	var msgs []models.ControlMessage
+	if err := json.Unmarshal(data, &msgs); err != nil {
+		return
+	}
+	for _, cm := range msgs {
+		switch cm.Status {
+		case "auth_success":
+			c.advance(Authenticating, Connected, ReasonNone, nil)
+		case "auth_failed":
+			c.advance(Authenticating, Disconnected, ReasonAuthFailed, errAuthFailed)
+		}
+	}
+}
+
 func (c *Client) handleData(eventType string, msg json.RawMessage) {
 	if c.rawData {
-		c.output <- msg // push raw JSON to output channel
+		c.out.push(eventType, msg) // push raw JSON to output channel
 		return
 	}
 
@@ -443,7 +605,7 @@ func (c *Client) handleData(eventType string, msg json.RawMessage) {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "AM":
 		switch c.market {
 		case Forex, Crypto:
@@ -453,14 +615,14 @@ func (c *Client) handleData(eventType string, msg json.RawMessage) {
 					c.log.Errorf("failed to unmarshal message: %v", err)
 					return
 				}
-				c.output <- out
+				c.emit(eventType, out)
 			} else {
 				var out models.CurrencyAgg
 				if err := json.Unmarshal(msg, &out); err != nil {
 					c.log.Errorf("failed to unmarshal message: %v", err)
 					return
 				}
-				c.output <- out
+				c.emit(eventType, out)
 			}
 
 		default:
@@ -469,7 +631,7 @@ func (c *Client) handleData(eventType string, msg json.RawMessage) {
 				c.log.Errorf("failed to unmarshal message: %v", err)
 				return
 			}
-			c.output <- out
+			c.emit(eventType, out)
 		}
 	case "CA", "CAS":
 		var out models.CurrencyAgg
@@ -477,90 +639,91 @@ func (c *Client) handleData(eventType string, msg json.RawMessage) {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "XA", "XAS":
 		var out models.CurrencyAgg
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "T":
 		var out models.EquityTrade
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "XT":
 		var out models.CryptoTrade
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "Q":
 		var out models.EquityQuote
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "C":
 		var out models.ForexQuote
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "XQ":
 		var out models.CryptoQuote
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "NOI":
 		var out models.Imbalance
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "LULD":
 		var out models.LimitUpLimitDown
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "XL2":
 		var out models.Level2Book
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "V":
 		var out models.IndexValue
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "LV":
 		var out models.LaunchpadValue
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
 			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 	case "FMV":
 		var out models.FairMarketValue
 		if err := json.Unmarshal(msg, &out); err != nil {
 			c.log.Errorf("failed to unmarshal message: %v", err)
+			return
 		}
-		c.output <- out
+		c.emit(eventType, out)
 
 	default:
 		c.log.Infof("unknown message type '%s'", sanitize(eventType))
@@ -0,0 +1,151 @@
+package polygonwstest
+
+import (
+	"fmt"
+
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// sampleEpoch is the timestamp of the first generated event in Unix MS (2024-01-02 09:30 ET).
+const sampleEpoch = 1704205800000
+
+// Sample returns a generated event of an event type for a symbol. Every prefix a client can subscribe to is
+// supported: A, AM, T, Q, NOI, LULD, LV, CA, CAS, C, XA, XAS, XT, XQ, XL2, V, and FMV. The sequence number n is used
+// for the sequence number of trades and quotes, offsets the timestamp by n milliseconds, and moves prices slightly so
+// consecutive events differ.
+func Sample(eventType, symbol string, n int64) (any, error) {
+	ev := models.EventType{EventType: eventType}
+	ts := sampleEpoch + n
+	price := 100 + float64(n%100)/100
+
+	switch eventType {
+	case "A", "AM":
+		return models.EquityAgg{
+			EventType:         ev,
+			Symbol:            symbol,
+			Volume:            1000,
+			AccumulatedVolume: float64(1000 * n),
+			VWAP:              price,
+			Open:              price - 0.05,
+			Close:             price,
+			High:              price + 0.05,
+			Low:               price - 0.1,
+			AggregateVWAP:     price,
+			AverageSize:       100,
+			StartTimestamp:    ts - 1000,
+			EndTimestamp:      ts,
+		}, nil
+	case "CA", "CAS", "XA", "XAS":
+		return models.CurrencyAgg{
+			EventType:      ev,
+			Pair:           symbol,
+			Open:           price - 0.05,
+			Close:          price,
+			High:           price + 0.05,
+			Low:            price - 0.1,
+			Volume:         10,
+			VWAP:           price,
+			StartTimestamp: ts - 1000,
+			EndTimestamp:   ts,
+		}, nil
+	case "T":
+		return models.EquityTrade{
+			EventType:      ev,
+			Symbol:         symbol,
+			Exchange:       4,
+			ID:             fmt.Sprint(n),
+			Tape:           3,
+			Price:          price,
+			Size:           100,
+			Timestamp:      ts,
+			SequenceNumber: n,
+		}, nil
+	case "Q":
+		return models.EquityQuote{
+			EventType:      ev,
+			Symbol:         symbol,
+			BidExchangeID:  11,
+			BidPrice:       price - 0.01,
+			BidSize:        2,
+			AskExchangeID:  12,
+			AskPrice:       price + 0.01,
+			AskSize:        3,
+			Timestamp:      ts,
+			Tape:           3,
+			SequenceNumber: n,
+		}, nil
+	case "NOI":
+		return models.Imbalance{
+			EventType:         ev,
+			Symbol:            symbol,
+			Timestamp:         ts,
+			AuctionTime:       1600,
+			AuctionType:       "C",
+			SymbolSequence:    int32(n),
+			ExchangeID:        10,
+			ImbalanceQuantity: 5000,
+			PairedQuantity:    20000,
+			BookClearingPrice: price,
+		}, nil
+	case "LULD":
+		return models.LimitUpLimitDown{
+			EventType:      ev,
+			Symbol:         symbol,
+			HighPrice:      price * 1.05,
+			LowPrice:       price * 0.95,
+			Tape:           3,
+			Timestamp:      ts,
+			SequenceNumber: n,
+		}, nil
+	case "C":
+		return models.ForexQuote{
+			EventType:  ev,
+			Pair:       symbol,
+			ExchangeID: 48,
+			AskPrice:   price/100 + 0.0001,
+			BidPrice:   price/100 - 0.0001,
+			Timestamp:  ts,
+		}, nil
+	case "XT":
+		return models.CryptoTrade{
+			EventType:         ev,
+			Pair:              symbol,
+			Exchange:          1,
+			ID:                fmt.Sprint(n),
+			Price:             price,
+			Size:              0.5,
+			Conditions:        []int32{1},
+			Timestamp:         ts,
+			ReceivedTimestamp: ts + 1,
+		}, nil
+	case "XQ":
+		return models.CryptoQuote{
+			EventType:         ev,
+			Pair:              symbol,
+			BidPrice:          price - 0.01,
+			BidSize:           1,
+			AskPrice:          price + 0.01,
+			AskSize:           1,
+			Timestamp:         ts,
+			ExchangeID:        1,
+			ReceivedTimestamp: ts + 1,
+		}, nil
+	case "XL2":
+		return models.Level2Book{
+			EventType:         ev,
+			Pair:              symbol,
+			BidPrices:         [][]float64{{price - 0.01, 1}, {price - 0.02, 2}},
+			AskPrices:         [][]float64{{price + 0.01, 1}, {price + 0.02, 2}},
+			Timestamp:         ts,
+			ExchangeID:        1,
+			ReceivedTimestamp: ts + 1,
+		}, nil
+	case "V":
+		return models.IndexValue{EventType: ev, Value: price * 40, Ticker: symbol, Timestamp: ts}, nil
+	case "LV":
+		return models.LaunchpadValue{EventType: ev, Value: price, Ticker: symbol, Timestamp: ts * 1e6}, nil
+	case "FMV":
+		return models.FairMarketValue{EventType: ev, FMV: price, Ticker: symbol, Timestamp: ts * 1e6}, nil
+	}
+	return nil, fmt.Errorf("unknown event type '%v'", eventType)
+}
@@ -0,0 +1,362 @@
+// Package polygonwstest provides a local emulator of the Polygon WebSocket API for tests and development.
+//
+// The server implements the authentication handshake, acknowledges subscriptions, and only delivers events to the
+// connections subscribed to their topic, like the real feeds. Tests script the stream with Send and Generate, and can
+// exercise error paths with Disconnect, SendRaw, and SetLatency:
+//
+//	s := polygonwstest.NewServer("API_KEY")
+//	defer s.Close()
+//
+//	c, _ := polygonws.New(polygonws.Config{APIKey: "API_KEY", Feed: polygonws.Feed(s.URL()), Market: polygonws.Stocks})
+//	_ = c.Subscribe(polygonws.StocksTrades, "AAPL")
+//	_ = c.Connect()
+//	_ = s.WaitForSubscription(ctx, "T.AAPL")
+//	s.Generate("T", "AAPL", 10)
+package polygonwstest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/polygon-io/client-go/websocket/internal/message"
+	"github.com/polygon-io/client-go/websocket/models"
+)
+
+// Server is a local WebSocket server that behaves like the Polygon WebSocket API.
+type Server struct {
+	apiKey string
+	srv    *httptest.Server
+
+	mtx     sync.Mutex
+	conns   map[*conn]struct{}
+	latency time.Duration
+	changed chan struct{}
+	rejects map[string]string
+}
+
+type conn struct {
+	ws *websocket.Conn
+
+	mtx    sync.Mutex
+	authed bool
+	subs   map[string]struct{}
+}
+
+// NewServer starts a server that accepts the given API key.
+func NewServer(apiKey string) *Server {
+	s := &Server{
+		apiKey:  apiKey,
+		conns:   make(map[*conn]struct{}),
+		changed: make(chan struct{}),
+		rejects: make(map[string]string),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the WebSocket URL of the server, which can be used as the client feed. The market the client appends
+// to the path is accepted but not checked against the subscribed topics.
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// Close disconnects every client and shuts the server down.
+func (s *Server) Close() {
+	s.Disconnect()
+	s.srv.Close()
+}
+
+// Connections returns the number of open connections.
+func (s *Server) Connections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.conns)
+}
+
+// Subscriptions returns the sorted topic parameters (e.g. "T.AAPL") that authenticated connections are subscribed to.
+func (s *Server) Subscriptions() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	seen := make(map[string]struct{})
+	for c := range s.conns {
+		c.mtx.Lock()
+		for sub := range c.subs {
+			seen[sub] = struct{}{}
+		}
+		c.mtx.Unlock()
+	}
+
+	subs := make([]string, 0, len(seen))
+	for sub := range seen {
+		subs = append(subs, sub)
+	}
+	sort.Strings(subs)
+	return subs
+}
+
+// WaitForSubscription blocks until a connection is subscribed to the topic parameter (e.g. "T.AAPL") or the context
+// is done. Since clients subscribe asynchronously, tests should wait before sending events.
+func (s *Server) WaitForSubscription(ctx context.Context, param string) error {
+	for {
+		s.mtx.Lock()
+		changed := s.changed
+		s.mtx.Unlock()
+
+		for _, sub := range s.Subscriptions() {
+			if sub == param {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// SetLatency delays every frame written by the server, including status messages, by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.latency = d
+}
+
+// Reject makes the server reply to subscriptions to a topic parameter (e.g. "T.AAPL") with an error status message,
+// as if the plan isn't entitled to it.
+func (s *Server) Reject(param, message string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.rejects[param] = message
+}
+
+// Send delivers events in a single frame to every authenticated connection subscribed to their topic. Events can be
+// any of the message types in the models package (e.g. models.EquityTrade) or anything else that marshals to a JSON
+// object with an "ev" field. Each connection only receives the events it's subscribed to, and nothing if there are
+// none.
+func (s *Server) Send(events ...any) error {
+	msgs := make([]json.RawMessage, 0, len(events))
+	for _, ev := range events {
+		msg, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	for _, c := range s.connections() {
+		var frame []json.RawMessage
+		for _, msg := range msgs {
+			if c.subscribed(msg) {
+				frame = append(frame, msg)
+			}
+		}
+		if len(frame) > 0 {
+			s.write(c, frame)
+		}
+	}
+	return nil
+}
+
+// Generate sends n generated events of an event type (e.g. "T" or "XQ") for a symbol in a single frame. Sequence
+// numbers and timestamps increase from one event to the next.
+func (s *Server) Generate(eventType, symbol string, n int) error {
+	events := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		ev, err := Sample(eventType, symbol, int64(i+1))
+		if err != nil {
+			return err
+		}
+		events = append(events, ev)
+	}
+	return s.Send(events...)
+}
+
+// SendRaw writes a frame as is to every authenticated connection regardless of their subscriptions, e.g. to test how a
+// client handles malformed JSON.
+func (s *Server) SendRaw(frame []byte) {
+	for _, c := range s.connections() {
+		s.writeRaw(c, frame)
+	}
+}
+
+// Disconnect drops every connection without a close handshake, as if the network failed.
+func (s *Server) Disconnect() {
+	s.mtx.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mtx.Unlock()
+
+	for _, c := range conns {
+		_ = c.ws.UnderlyingConn().Close()
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{ws: ws, subs: make(map[string]struct{})}
+	s.mtx.Lock()
+	s.conns[c] = struct{}{}
+	s.mtx.Unlock()
+	s.notify()
+
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.mtx.Unlock()
+		s.notify()
+		_ = ws.Close()
+	}()
+
+	s.status(c, "connected", "Connected Successfully")
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var cm models.ControlMessage
+		if err := json.Unmarshal(data, &cm); err != nil {
+			s.status(c, "error", "Invalid JSON")
+			continue
+		}
+		s.control(c, cm)
+	}
+}
+
+func (s *Server) control(c *conn, cm models.ControlMessage) {
+	switch cm.Action {
+	case models.Auth:
+		if cm.Params != s.apiKey {
+			s.status(c, "auth_failed", "authentication failed")
+			return
+		}
+		c.mtx.Lock()
+		c.authed = true
+		c.mtx.Unlock()
+		s.status(c, "auth_success", "authenticated")
+	case models.Subscribe, models.Unsubscribe:
+		c.mtx.Lock()
+		authed := c.authed
+		c.mtx.Unlock()
+		if !authed {
+			s.status(c, "error", "you are not authenticated")
+			return
+		}
+
+		for _, param := range strings.Split(cm.Params, ",") {
+			param = strings.TrimSpace(param)
+			if param == "" {
+				continue
+			}
+			s.mtx.Lock()
+			reject, rejected := s.rejects[param]
+			s.mtx.Unlock()
+			if rejected && cm.Action == models.Subscribe {
+				s.status(c, "error", reject)
+				continue
+			}
+
+			c.mtx.Lock()
+			if cm.Action == models.Subscribe {
+				c.subs[param] = struct{}{}
+			} else {
+				delete(c.subs, param)
+			}
+			c.mtx.Unlock()
+
+			if cm.Action == models.Subscribe {
+				s.status(c, "success", "subscribed to: "+param)
+			} else {
+				s.status(c, "success", "unsubscribed to: "+param)
+			}
+		}
+		s.notify()
+	default:
+		s.status(c, "error", "unknown action")
+	}
+}
+
+// notify wakes up every WaitForSubscription call.
+func (s *Server) notify() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *Server) status(c *conn, status, message string) {
+	msg, _ := json.Marshal(models.ControlMessage{
+		EventType: models.EventType{EventType: "status"},
+		Status:    status,
+		Message:   message,
+	})
+	s.write(c, []json.RawMessage{msg})
+}
+
+func (s *Server) write(c *conn, msgs []json.RawMessage) {
+	frame, err := json.Marshal(msgs)
+	if err != nil {
+		return
+	}
+	s.writeRaw(c, frame)
+}
+
+func (s *Server) writeRaw(c *conn, frame []byte) {
+	s.mtx.Lock()
+	latency := s.latency
+	s.mtx.Unlock()
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	_ = c.ws.WriteMessage(websocket.TextMessage, frame)
+}
+
+// connections returns the authenticated connections.
+func (s *Server) connections() []*conn {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		c.mtx.Lock()
+		if c.authed {
+			conns = append(conns, c)
+		}
+		c.mtx.Unlock()
+	}
+	return conns
+}
+
+func (c *conn) subscribed(msg json.RawMessage) bool {
+	ev, sym, err := message.EventSymbol(msg)
+	if err != nil {
+		return false
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.subs[ev+".*"]; ok {
+		return true
+	}
+	_, ok := c.subs[ev+"."+sym]
+	return ok
+}
@@ -0,0 +1,112 @@
+package polygonwstest_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	polygonws "github.com/polygon-io/client-go/websocket"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/polygon-io/client-go/websocket/polygonwstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func next(t *testing.T, c *polygonws.Client) any {
+	select {
+	case out := <-c.Output():
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a message")
+		return nil
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := polygonwstest.NewServer("good")
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reconnected := make(chan struct{}, 1)
+	c, err := polygonws.New(polygonws.Config{
+		APIKey: "good",
+		Feed:   polygonws.Feed(s.URL()),
+		Market: polygonws.Stocks,
+		ReconnectCallback: func(err error) {
+			if err == nil {
+				reconnected <- struct{}{}
+			}
+		},
+	})
+	assert.Nil(t, err)
+	defer c.Close()
+
+	assert.Nil(t, c.Subscribe(polygonws.StocksTrades, "AAPL"))
+	assert.Nil(t, c.Connect())
+	assert.Nil(t, s.WaitForSubscription(ctx, "T.AAPL"))
+	assert.Equal(t, []string{"T.AAPL"}, s.Subscriptions())
+
+	// only subscribed topics are delivered
+	assert.Nil(t, s.Generate("T", "MSFT", 1))
+	assert.Nil(t, s.Generate("T", "AAPL", 2))
+	assert.Equal(t, int64(1), next(t, c).(models.EquityTrade).SequenceNumber)
+	assert.Equal(t, int64(2), next(t, c).(models.EquityTrade).SequenceNumber)
+
+	// malformed frames are skipped by the client
+	s.SendRaw([]byte("not json"))
+	assert.Nil(t, s.Send(models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL", Price: 1}))
+	assert.Equal(t, 1.0, next(t, c).(models.EquityTrade).Price)
+
+	// the client reconnects and resubscribes after a dropped connection
+	s.SetLatency(10 * time.Millisecond)
+	s.Disconnect()
+	select {
+	case <-reconnected:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for a reconnect")
+	}
+	assert.Nil(t, s.WaitForSubscription(ctx, "T.AAPL"))
+	assert.Nil(t, s.Generate("T", "AAPL", 1))
+	assert.Equal(t, "AAPL", next(t, c).(models.EquityTrade).Symbol)
+
+	// unsubscribing stops delivery
+	assert.Nil(t, c.Unsubscribe(polygonws.StocksTrades, "AAPL"))
+	assert.Nil(t, c.Subscribe(polygonws.StocksQuotes, "*"))
+	assert.Nil(t, s.WaitForSubscription(ctx, "Q.*"))
+	assert.Nil(t, s.Generate("T", "AAPL", 1))
+	assert.Nil(t, s.Generate("Q", "AAPL", 1))
+	_, ok := next(t, c).(models.EquityQuote)
+	assert.True(t, ok)
+}
+
+func TestServerAuthFailed(t *testing.T) {
+	s := polygonwstest.NewServer("good")
+	defer s.Close()
+
+	var retries uint64 = 0
+	c, err := polygonws.New(polygonws.Config{
+		APIKey:     "bad",
+		Feed:       polygonws.Feed(s.URL()),
+		Market:     polygonws.Stocks,
+		MaxRetries: &retries,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+
+	select {
+	case err := <-c.Error():
+		assert.NotNil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the auth error")
+	}
+}
+
+func TestSample(t *testing.T) {
+	for _, ev := range []string{"A", "AM", "T", "Q", "NOI", "LULD", "LV", "CA", "CAS", "C", "XA", "XAS", "XT", "XQ", "XL2", "V", "FMV"} {
+		_, err := polygonwstest.Sample(ev, "X:BTC-USD", 1)
+		assert.Nil(t, err, ev)
+	}
+	_, err := polygonwstest.Sample("??", "AAPL", 1)
+	assert.NotNil(t, err)
+}
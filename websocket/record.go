@@ -0,0 +1,135 @@
+package polygonws
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// captureMagic identifies the start of a capture so that replaying an unrelated file fails early.
+const captureMagic = "PGWSCAP1"
+
+// Recorder writes every raw frame read by a client into a gzip compressed capture, along with the time it was
+// received. Captures can be replayed with a ReplayClient. A Recorder is safe for concurrent use.
+type Recorder struct {
+	mtx    sync.Mutex
+	gz     *gzip.Writer
+	closer io.Closer
+	err    error
+}
+
+// NewRecorder returns a recorder that writes a capture to w. Close must be called to flush the capture, but it doesn't
+// close w.
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write([]byte(captureMagic)); err != nil {
+		return nil, fmt.Errorf("failed to write capture header: %w", err)
+	}
+	return &Recorder{gz: gz}, nil
+}
+
+// CreateCapture creates or truncates the named file and returns a recorder that writes a capture to it. Closing the
+// recorder also closes the file.
+func CreateCapture(name string) (*Recorder, error) {
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewRecorder(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Record appends a frame received at the given time to the capture.
+func (r *Recorder) Record(received time.Time, frame []byte) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+	if len(frame) > maxMessageSize {
+		return fmt.Errorf("frame of %v bytes exceeds the maximum of %v", len(frame), maxMessageSize)
+	}
+
+	var header [12]byte
+	binary.BigEndian.PutUint64(header[:8], uint64(received.UnixNano()))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(frame)))
+	if _, err := r.gz.Write(header[:]); err != nil {
+		r.err = fmt.Errorf("failed to write frame: %w", err)
+		return r.err
+	}
+	if _, err := r.gz.Write(frame); err != nil {
+		r.err = fmt.Errorf("failed to write frame: %w", err)
+		return r.err
+	}
+	return nil
+}
+
+// Close flushes the capture and closes the underlying file if the recorder was created with CreateCapture.
+func (r *Recorder) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.err == nil {
+		r.err = errors.New("recorder is closed")
+	}
+	err := r.gz.Close()
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// captureReader reads the frames of a capture in order.
+type captureReader struct {
+	r *bufio.Reader
+}
+
+func newCaptureReader(r io.Reader) (*captureReader, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capture: %w", err)
+	}
+
+	br := bufio.NewReader(gz)
+	magic := make([]byte, len(captureMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != captureMagic {
+		return nil, errors.New("invalid capture: missing header")
+	}
+	return &captureReader{r: br}, nil
+}
+
+// next returns the next frame and the time it was received, or io.EOF at the end of the capture.
+func (cr *captureReader) next() (time.Time, []byte, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(cr.r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return time.Time{}, nil, errors.New("truncated capture")
+		}
+		return time.Time{}, nil, err
+	}
+
+	// frames are at most as large as the client reads, so anything larger is corrupt and mustn't be allocated
+	size := binary.BigEndian.Uint32(header[8:])
+	if size > maxMessageSize {
+		return time.Time{}, nil, fmt.Errorf("invalid capture: frame of %v bytes exceeds the maximum of %v", size, maxMessageSize)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(cr.r, frame); err != nil {
+		return time.Time{}, nil, errors.New("truncated capture")
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(header[:8]))), frame, nil
+}
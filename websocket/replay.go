@@ -0,0 +1,243 @@
+package polygonws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/polygon-io/client-go/websocket/internal/message"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"gopkg.in/tomb.v2"
+)
+
+// ReplayClient replays a capture written by a Recorder through the same routing as a live client, so the typed
+// messages pushed to Output and passed to handlers are identical to the recorded session. The embedded Client
+// provides the handler registration methods (e.g. OnTrade) and Dropped.
+type ReplayClient struct {
+	*Client
+
+	capture io.Reader
+	speed   float64
+
+	mtx     sync.Mutex
+	started bool
+	closed  bool
+	tomb    tomb.Tomb
+}
+
+// NewReplayClient creates a client that replays a capture. A speed of 1 replays the frames at the pace they were
+// received, a speed of N replays them N times faster, and a speed of zero or less replays them as fast as possible.
+// The APIKey and Feed of the config are ignored.
+func NewReplayClient(config Config, capture io.Reader, speed float64) (*ReplayClient, error) {
+	if config.APIKey == "" {
+		config.APIKey = "replay"
+	}
+	c, err := New(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayClient{Client: c, capture: capture, speed: speed}, nil
+}
+
+// Connect starts replaying the capture. Once every frame has been replayed, the output channel is closed.
+func (r *ReplayClient) Connect() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.started || r.closed {
+		return nil
+	}
+
+	cr, err := newCaptureReader(r.capture)
+	if err != nil {
+		return err
+	}
+	r.started = true
+	// there's no server to authenticate with, so the replay is connected right away
+	r.setState(Connected, ReasonNone, nil)
+	r.tomb.Go(func() error { return r.replay(cr) })
+
+	return nil
+}
+
+// Subscribe filters the replayed data messages to a topic and set of tickers. If no tickers are passed, every ticker
+// of the topic is replayed. If there are no subscriptions at all, every message in the capture is replayed.
+func (r *ReplayClient) Subscribe(topic Topic, tickers ...string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.market.supports(topic) {
+		return fmt.Errorf("topic '%v' not supported for market '%v'", topic.prefix(), r.market)
+	}
+
+	if len(tickers) == 0 || slices.Contains(tickers, "*") {
+		tickers = []string{"*"}
+	}
+	r.subs.add(topic, tickers...)
+
+	return nil
+}
+
+// SubscribeAndWait subscribes like Subscribe. There is no server to reject a subscription, so every ticker succeeds.
+func (r *ReplayClient) SubscribeAndWait(_ context.Context, topic Topic, tickers ...string) ([]SubscriptionResult, error) {
+	if err := r.Subscribe(topic, tickers...); err != nil {
+		return nil, err
+	}
+	if len(tickers) == 0 || slices.Contains(tickers, "*") {
+		tickers = []string{"*"}
+	}
+	res := make([]SubscriptionResult, len(tickers))
+	for i, ticker := range tickers {
+		res[i] = SubscriptionResult{Ticker: ticker}
+	}
+	return res, nil
+}
+
+// Unsubscribe stops replaying a topic for a set of tickers. If no tickers are passed, it will unsubscribe from all
+// tickers for a given topic.
+func (r *ReplayClient) Unsubscribe(topic Topic, tickers ...string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if !r.market.supports(topic) {
+		return fmt.Errorf("topic '%v' not supported for market '%v'", topic.prefix(), r.market)
+	}
+
+	if len(tickers) == 0 || slices.Contains(tickers, "*") {
+		tickers = maps.Keys(r.subs[topic])
+	}
+	r.subs.delete(topic, tickers...)
+
+	return nil
+}
+
+// Subscriptions returns the tickers per topic that are replayed.
+func (r *ReplayClient) Subscriptions() map[Topic][]string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	out := make(map[Topic][]string, len(r.subs))
+	for topic, tickers := range r.subs {
+		keys := maps.Keys(tickers)
+		slices.Sort(keys)
+		out[topic] = keys
+	}
+	return out
+}
+
+// Close stops the replay and closes the output channel. Messages that are waiting for room in the output channel are
+// discarded.
+func (r *ReplayClient) Close() {
+	r.mtx.Lock()
+	if r.closed {
+		r.mtx.Unlock()
+		return
+	}
+	r.closed = true
+	started := r.started
+	r.mtx.Unlock()
+
+	if started {
+		r.out.stop()
+		r.tomb.Kill(nil)
+		_ = r.tomb.Wait()
+		return
+	}
+	r.closeOutput()
+	r.setState(Closed, ReasonNone, nil)
+}
+
+func (r *ReplayClient) replay(cr *captureReader) (err error) {
+	defer func() {
+		r.Client.closeOutput()
+		r.setState(Closed, ReasonNone, nil)
+		if err != nil {
+			r.log.Errorf("replay stopped: %v", err)
+			r.fail(err)
+		}
+	}()
+
+	var first, start time.Time
+	for {
+		received, frame, err := cr.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if r.speed > 0 {
+			if first.IsZero() {
+				first, start = received, time.Now()
+			}
+			wait := time.Until(start.Add(time.Duration(float64(received.Sub(first)) / r.speed)))
+			if wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-r.tomb.Dying():
+					timer.Stop()
+					return nil
+				case <-timer.C:
+				}
+			}
+		}
+
+		select {
+		case <-r.tomb.Dying():
+			return nil
+		default:
+		}
+
+		if err := r.handleFrame(r.filter(frame)); err != nil {
+			return err
+		}
+	}
+}
+
+// filter drops the data messages of a frame that aren't subscribed to. Status messages are always kept.
+func (r *ReplayClient) filter(frame []byte) []byte {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.subs) == 0 || (r.rawData && r.bypassRawDataRouting) {
+		return frame
+	}
+
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(frame, &msgs); err != nil {
+		return frame // let the router report it
+	}
+
+	kept := msgs[:0]
+	for _, msg := range msgs {
+		ev, sym, err := message.EventSymbol(msg)
+		if err == nil && (ev == "status" || r.subscribed(ev, sym)) {
+			kept = append(kept, msg)
+		}
+	}
+	out, err := json.Marshal(kept)
+	if err != nil {
+		return frame
+	}
+	return out
+}
+
+func (r *ReplayClient) subscribed(eventType, symbol string) bool {
+	for topic, tickers := range r.subs {
+		if topic.prefix() != eventType {
+			continue
+		}
+		if _, ok := tickers["*"]; ok {
+			return true
+		}
+		if _, ok := tickers[symbol]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package polygonws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const replayFrames = `[{"ev":"T","sym":"AAPL","p":180.5,"s":100,"q":1}]
+[{"ev":"Q","sym":"AAPL","bp":180.4,"ap":180.6,"q":2},{"ev":"T","sym":"MSFT","p":370.1,"s":10,"q":1}]
+[{"ev":"C","p":"EUR/USD","a":1.1,"b":1.09}]`
+
+func TestRecordAndReplay(t *testing.T) {
+	// serve a few data frames after authenticating
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`[{"ev":"status","status":"auth_success"}]`))
+		for _, frame := range strings.Split(replayFrames, "\n") {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(frame))
+		}
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer s.Close()
+
+	var capture bytes.Buffer
+	rec, err := NewRecorder(&capture)
+	assert.Nil(t, err)
+
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:     "good",
+		Feed:       Feed("ws" + strings.TrimPrefix(s.URL, "http")),
+		Market:     Market(""),
+		MaxRetries: &retries,
+		Recorder:   rec,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+
+	var live []any
+	for len(live) < 4 {
+		select {
+		case out := <-c.Output():
+			live = append(live, out)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for live messages")
+		}
+	}
+	c.Close()
+	assert.Nil(t, rec.Close())
+
+	// replaying everything produces the same typed messages
+	r, err := NewReplayClient(Config{Market: Market("")}, bytes.NewReader(capture.Bytes()), 0)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Connect())
+	var replayed []any
+	for out := range r.Output() {
+		replayed = append(replayed, out)
+	}
+	assert.Equal(t, live, replayed)
+
+	// subscriptions filter the replayed messages and handlers work as they do live
+	r, err = NewReplayClient(Config{Market: Stocks}, bytes.NewReader(capture.Bytes()), 1000)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Subscribe(StocksTrades, "AAPL", "MSFT"))
+	assert.Nil(t, r.Unsubscribe(StocksTrades, "MSFT"))
+	var trades []models.EquityTrade
+	r.OnTrade(func(trade models.EquityTrade) { trades = append(trades, trade) })
+	assert.Nil(t, r.Connect())
+	for range r.Output() {
+		t.Fatal("unexpected unclaimed message")
+	}
+	assert.Len(t, trades, 1)
+	assert.Equal(t, "AAPL", trades[0].Symbol)
+	r.Close()
+
+	// not a capture
+	r, err = NewReplayClient(Config{}, strings.NewReader("not a capture"), 0)
+	assert.Nil(t, err)
+	assert.NotNil(t, r.Connect())
+	r.Close()
+}
+
+func TestReplayCorruptFrameSize(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, _ = gz.Write([]byte(captureMagic))
+	var header [12]byte
+	binary.BigEndian.PutUint32(header[8:], math.MaxUint32)
+	_, _ = gz.Write(header[:])
+	assert.Nil(t, gz.Close())
+
+	// the length prefix is rejected before anything is allocated for it
+	cr, err := newCaptureReader(&buf)
+	assert.Nil(t, err)
+	_, _, err = cr.next()
+	assert.ErrorContains(t, err, "exceeds the maximum")
+
+	rec, err := NewRecorder(io.Discard)
+	assert.Nil(t, err)
+	assert.NotNil(t, rec.Record(time.Now(), make([]byte, maxMessageSize+1)))
+	assert.Nil(t, rec.Record(time.Now(), []byte("[]")))
+}
+
+func TestReplayStateAndClose(t *testing.T) {
+	var capture bytes.Buffer
+	rec, err := NewRecorder(&capture)
+	assert.Nil(t, err)
+	for _, frame := range strings.Split(replayFrames, "\n") {
+		assert.Nil(t, rec.Record(time.Now(), []byte(frame)))
+	}
+	assert.Nil(t, rec.Close())
+
+	// nothing reads the output, so the replay blocks once it's full
+	r, err := NewReplayClient(Config{Market: Market(""), OutputBufferSize: 1}, bytes.NewReader(capture.Bytes()), 0)
+	assert.Nil(t, err)
+	events, stop := r.Events()
+	defer stop()
+	assert.Nil(t, r.Connect())
+	assert.Equal(t, Connected, r.State())
+	for len(r.Output()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		r.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out closing a blocked replay")
+	}
+	assert.Equal(t, Closed, r.State())
+
+	var states []State
+	for ev := range events {
+		states = append(states, ev.To)
+	}
+	assert.Equal(t, []State{Connected, Closed}, states)
+}
@@ -0,0 +1,241 @@
+package polygonws
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// authWait bounds how long Connect waits for the server to accept or reject the API key when WaitForAuth is set.
+const authWait = pongWait
+
+// stateEventBufferSize is the capacity of every event channel returned by Events.
+const stateEventBufferSize = 64
+
+var (
+	errAuthFailed  = errors.New("authentication failed")
+	errPingFailed  = errors.New("failed to send ping message")
+	errClientEnded = errors.New("client closed")
+)
+
+// State is the connection state of a client.
+type State int
+
+const (
+	// Disconnected is the state of a client before it connects and after a connection attempt fails.
+	Disconnected State = iota
+
+	// Connecting is the state of a client while it dials the server for the first time.
+	Connecting
+
+	// Authenticating is the state of a client that is connected and waiting for the server to accept its API key.
+	Authenticating
+
+	// Connected is the state of an authenticated client.
+	Connected
+
+	// Reconnecting is the state of a client that lost its connection and is dialing the server again.
+	Reconnecting
+
+	// Closed is the state of a client that was closed by the user or after a fatal error.
+	Closed
+)
+
+func (s State) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Connecting:
+		return "connecting"
+	case Authenticating:
+		return "authenticating"
+	case Connected:
+		return "connected"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Reason is the cause of a state change.
+type Reason string
+
+const (
+	// ReasonNone is used for state changes that are part of the normal lifecycle (e.g. authenticating after a dial).
+	ReasonNone Reason = ""
+
+	// ReasonDialFailed means the server couldn't be reached.
+	ReasonDialFailed Reason = "dial_failed"
+
+	// ReasonAuthFailed means the server rejected the API key.
+	ReasonAuthFailed Reason = "auth_failed"
+
+	// ReasonReadTimeout means the server didn't send anything, including pongs, within the read deadline.
+	ReasonReadTimeout Reason = "read_timeout"
+
+	// ReasonServerClose means the server closed the connection with a close frame. The close code is set on the
+	// event.
+	ReasonServerClose Reason = "server_close"
+
+	// ReasonPingFailed means a ping couldn't be written to the connection.
+	ReasonPingFailed Reason = "ping_failed"
+
+	// ReasonConnectionLost covers every other read or write failure (e.g. the connection was dropped without a close
+	// frame).
+	ReasonConnectionLost Reason = "connection_lost"
+)
+
+// StateEvent is a state change of a client.
+type StateEvent struct {
+	From State
+	To   State
+
+	// Reason is why the state changed, and Err is the underlying error if there is one.
+	Reason Reason
+	Err    error
+
+	// CloseCode is the code of the close frame sent by the server (e.g. 1008) if Reason is ReasonServerClose.
+	CloseCode int
+
+	Time time.Time
+}
+
+// State returns the current connection state.
+func (c *Client) State() State {
+	c.stateMtx.Lock()
+	defer c.stateMtx.Unlock()
+	return c.state
+}
+
+// Events returns a channel of state changes and a function that stops them. The channel is closed when the client
+// is closed or the stop function is called. Events are dropped for consumers that fall more than 64 events behind,
+// so State should be used to read the current state.
+func (c *Client) Events() (<-chan StateEvent, func()) {
+	c.stateMtx.Lock()
+	defer c.stateMtx.Unlock()
+
+	ch := make(chan StateEvent, stateEventBufferSize)
+	if c.state == Closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if c.watchers == nil {
+		c.watchers = make(map[chan StateEvent]struct{})
+	}
+	c.watchers[ch] = struct{}{}
+
+	return ch, func() {
+		c.stateMtx.Lock()
+		defer c.stateMtx.Unlock()
+		if _, ok := c.watchers[ch]; ok {
+			delete(c.watchers, ch)
+			close(ch)
+		}
+	}
+}
+
+// setState moves the client to a new state and notifies the watchers. A client that is closed after a failure
+// reports the reason of the failure.
+func (c *Client) setState(to State, reason Reason, err error) {
+	c.stateMtx.Lock()
+	defer c.stateMtx.Unlock()
+	c.transition(to, reason, err)
+}
+
+// advance moves the client to a new state only if it's in the expected one, so that status messages still queued
+// from a dropped connection don't change the state of the next one.
+func (c *Client) advance(from, to State, reason Reason, err error) {
+	c.stateMtx.Lock()
+	defer c.stateMtx.Unlock()
+	if c.state == from {
+		c.transition(to, reason, err)
+	}
+}
+
+func (c *Client) transition(to State, reason Reason, err error) {
+	if c.state == to {
+		return
+	}
+	if to == Closed && reason == ReasonNone && c.state == Disconnected {
+		reason, err = c.lastEvent.Reason, c.lastEvent.Err
+	}
+
+	ev := StateEvent{From: c.state, To: to, Reason: reason, Err: err, Time: time.Now()}
+	var ce *websocket.CloseError
+	if reason == ReasonServerClose && errors.As(err, &ce) {
+		ev.CloseCode = ce.Code
+	}
+	c.state = to
+	c.lastEvent = ev
+	if reason == ReasonNone {
+		c.log.Debugf("connection state changed from %v to %v", ev.From, ev.To)
+	} else {
+		c.log.Debugf("connection state changed from %v to %v: %v", ev.From, ev.To, reason)
+	}
+
+	for ch := range c.watchers {
+		select {
+		case ch <- ev:
+		default:
+		}
+		if to == Closed {
+			close(ch)
+		}
+	}
+	if to == Closed {
+		c.watchers = nil
+	}
+}
+
+// awaitAuth blocks until a connection attempt succeeds or fails.
+func (c *Client) awaitAuth(events <-chan StateEvent) error {
+	if c.State() == Connected {
+		return nil
+	}
+
+	timeout := time.NewTimer(authWait)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				return errClientEnded
+			}
+			switch ev.To {
+			case Connected:
+				return nil
+			case Disconnected, Closed:
+				if ev.Err != nil {
+					return fmt.Errorf("failed to connect (%v): %w", ev.Reason, ev.Err)
+				}
+				return fmt.Errorf("failed to connect: %v", ev.To)
+			}
+		case <-timeout.C:
+			return fmt.Errorf("timed out waiting for authentication after %v", authWait)
+		}
+	}
+}
+
+// disconnectReason classifies the error that stopped the read/write threads.
+func disconnectReason(err error) Reason {
+	var ce *websocket.CloseError
+	var ne net.Error
+	switch {
+	case err == nil:
+		return ReasonNone
+	case errors.As(err, &ce) && ce.Code != websocket.CloseAbnormalClosure:
+		return ReasonServerClose
+	case errors.Is(err, errPingFailed):
+		return ReasonPingFailed
+	case errors.As(err, &ne) && ne.Timeout():
+		return ReasonReadTimeout
+	}
+	return ReasonConnectionLost
+}
@@ -0,0 +1,176 @@
+package polygonws
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/polygon-io/client-go/websocket/models"
+	"github.com/polygon-io/client-go/websocket/polygonwstest"
+	"github.com/stretchr/testify/assert"
+)
+
+func nextEvent(t *testing.T, events <-chan StateEvent) StateEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a state event")
+		return StateEvent{}
+	}
+}
+
+func TestState(t *testing.T) {
+	s := polygonwstest.NewServer("good")
+	defer s.Close()
+
+	c, err := New(Config{APIKey: "good", Feed: Feed(s.URL()), Market: Stocks, WaitForAuth: true})
+	assert.Nil(t, err)
+	assert.Equal(t, Disconnected, c.State())
+
+	events, stop := c.Events()
+	defer stop()
+
+	// connect waits until the client is authenticated
+	assert.Nil(t, c.Connect())
+	assert.Equal(t, Connected, c.State())
+	assert.Equal(t, Connecting, nextEvent(t, events).To)
+	assert.Equal(t, Authenticating, nextEvent(t, events).To)
+	assert.Equal(t, Connected, nextEvent(t, events).To)
+
+	// a dropped connection is reported with its reason
+	s.Disconnect()
+	ev := nextEvent(t, events)
+	assert.Equal(t, Connected, ev.From)
+	assert.Equal(t, Reconnecting, ev.To)
+	assert.Equal(t, ReasonConnectionLost, ev.Reason)
+	assert.NotNil(t, ev.Err)
+	assert.Equal(t, Authenticating, nextEvent(t, events).To)
+	assert.Equal(t, Connected, nextEvent(t, events).To)
+
+	// closing ends the event stream
+	c.Close()
+	assert.Equal(t, Closed, c.State())
+	assert.Equal(t, Closed, nextEvent(t, events).To)
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestStateAuthFailed(t *testing.T) {
+	s := polygonwstest.NewServer("good")
+	defer s.Close()
+
+	var retries uint64 = 0
+	c, err := New(Config{APIKey: "bad", Feed: Feed(s.URL()), Market: Stocks, MaxRetries: &retries, WaitForAuth: true})
+	assert.Nil(t, err)
+
+	err = c.Connect()
+	assert.True(t, errors.Is(err, errAuthFailed))
+	assert.Equal(t, Closed, c.State())
+	assert.NotNil(t, <-c.Error())
+
+	// the failed connection isn't dialed again
+	assert.ErrorIs(t, c.Connect(), errClientEnded)
+
+	// the client is closed because of the auth failure
+	events, _ := c.Events()
+	_, ok := <-events
+	assert.False(t, ok)
+	assert.Equal(t, ReasonAuthFailed, c.lastEvent.Reason)
+}
+
+func TestStateReconnectFailed(t *testing.T) {
+	s := polygonwstest.NewServer("good")
+
+	var retries uint64 = 0
+	c, err := New(Config{APIKey: "good", Feed: Feed(s.URL()), Market: Stocks, MaxRetries: &retries, WaitForAuth: true})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+
+	// the client gives up after failing to reconnect, and is closed for real
+	s.Close()
+	_, ok := <-c.Output()
+	assert.False(t, ok)
+	assert.Equal(t, Closed, c.State())
+	assert.ErrorIs(t, c.Connect(), errClientEnded)
+
+	// the error is kept for a late reader
+	select {
+	case err := <-c.Error():
+		assert.NotNil(t, err)
+	default:
+		t.Fatal("the error wasn't kept")
+	}
+}
+
+func TestStateServerClose(t *testing.T) {
+	var conns atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		first := conns.Add(1) == 1
+
+		if _, _, err := ws.ReadMessage(); err != nil {
+			return
+		}
+		res, _ := json.Marshal([]models.ControlMessage{{EventType: models.EventType{EventType: "status"}, Status: "auth_success"}})
+		if err := ws.WriteMessage(websocket.TextMessage, res); err != nil {
+			return
+		}
+		if first {
+			msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many connections")
+			_ = ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			return
+		}
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer s.Close()
+
+	c, err := New(Config{APIKey: "good", Feed: Feed("ws" + strings.TrimPrefix(s.URL, "http")), Market: Stocks})
+	assert.Nil(t, err)
+	events, stop := c.Events()
+	defer stop()
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+
+	for {
+		ev := nextEvent(t, events)
+		if ev.To != Reconnecting {
+			continue
+		}
+		assert.Equal(t, ReasonServerClose, ev.Reason)
+		assert.Equal(t, websocket.ClosePolicyViolation, ev.CloseCode)
+		break
+	}
+	assert.Equal(t, Authenticating, nextEvent(t, events).To)
+	assert.Equal(t, Connected, nextEvent(t, events).To)
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestDisconnectReason(t *testing.T) {
+	assert.Equal(t, ReasonNone, disconnectReason(nil))
+	assert.Equal(t, ReasonReadTimeout, disconnectReason(fmt.Errorf("failed to read message: %w", timeoutError{})))
+	assert.Equal(t, ReasonPingFailed, disconnectReason(fmt.Errorf("%w: %w", errPingFailed, timeoutError{})))
+	assert.Equal(t, ReasonServerClose, disconnectReason(fmt.Errorf("connection closed unexpectedly: %w", &websocket.CloseError{Code: websocket.CloseGoingAway})))
+	assert.Equal(t, ReasonConnectionLost, disconnectReason(&websocket.CloseError{Code: websocket.CloseAbnormalClosure}))
+	assert.Equal(t, ReasonConnectionLost, disconnectReason(errors.New("connection reset by peer")))
+}